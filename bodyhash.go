@@ -0,0 +1,81 @@
+package signer
+
+import (
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"mime"
+)
+
+// ErrBodyHashUnavailable is returned when the body hash algorithm isn't linked into the binary.
+var ErrBodyHashUnavailable = errors.New("signer: body hash algorithm is not available")
+
+// BodyHashPolicy decides whether oauth_body_hash is sent with a request of the
+// given method and Content-Type. The content type is empty when it isn't known.
+// Form-urlencoded requests never get oauth_body_hash, as the OAuth Request Body
+// Hash draft requires, so the policy isn't asked for them.
+type BodyHashPolicy func(method, contentType string) bool
+
+// AlwaysBodyHash sends oauth_body_hash with every request, as Mastercard APIs require. It is the default.
+func AlwaysBodyHash(method, contentType string) bool {
+	return true
+}
+
+// NeverBodyHash never sends oauth_body_hash.
+func NeverBodyHash(method, contentType string) bool {
+	return false
+}
+
+// WithBodyHashAlgorithm sets the hash of oauth_body_hash, e.g. crypto.SHA1 for
+// providers following the OAuth Request Body Hash draft. The default is crypto.SHA256.
+func WithBodyHashAlgorithm(algorithm crypto.Hash) Option {
+	return func(s *Signer) {
+		s.bodyHashAlgorithm = algorithm
+	}
+}
+
+// WithBodyHashPolicy sets when oauth_body_hash is sent. The default is AlwaysBodyHash.
+func WithBodyHashPolicy(policy BodyHashPolicy) Option {
+	return func(s *Signer) {
+		s.bodyHashPolicy = policy
+	}
+}
+
+// includeBodyHash tells whether oauth_body_hash is sent with the request.
+func (s *Signer) includeBodyHash(method, contentType string) (bool, error) {
+	if isFormContentType(contentType) || !s.bodyHashPolicy(method, contentType) {
+		return false, nil
+	}
+
+	if !s.bodyHashAlgorithm.Available() {
+		return false, ErrBodyHashUnavailable
+	}
+
+	return true, nil
+}
+
+// getBodyHash returns the body hash of the payload, or an empty string if the
+// policy skips oauth_body_hash for the request.
+func (s *Signer) getBodyHash(method, contentType string, payload []byte) (string, error) {
+	include, err := s.includeBodyHash(method, contentType)
+
+	if err != nil || !include {
+		return "", err
+	}
+
+	return getBodyHash(payload, s.bodyHashAlgorithm), nil
+}
+
+func getBodyHash(payload []byte, algorithm crypto.Hash) string {
+	h := algorithm.New()
+	h.Write(payload)
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// isFormContentType tells whether the content type is application/x-www-form-urlencoded.
+func isFormContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
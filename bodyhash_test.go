@@ -0,0 +1,140 @@
+package signer
+
+import (
+	"crypto"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyHashOptions(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skipWithoutBody := func(method, contentType string) bool {
+		return method != http.MethodGet && method != http.MethodHead
+	}
+
+	testCases := []struct {
+		name        string
+		opts        []Option
+		method      string
+		contentType string
+		body        string
+		want        string
+		wantErr     error
+	}{
+		{
+			name:   "Default",
+			method: http.MethodPost,
+			body:   "{}",
+			want:   `oauth_body_hash="RBNvo1WzZ4oRRq0W9%2BhknpT7T8If536DEMBg9hyq%2F4o%3D"`,
+		},
+		{
+			name:   "SHA-1",
+			opts:   []Option{WithBodyHashAlgorithm(crypto.SHA1)},
+			method: http.MethodPost,
+			body:   "",
+			want:   `oauth_body_hash="2jmj7l5rSw0yVb%2FvlWAYkK%2FYBwk%3D"`,
+		},
+		{
+			name:   "Never",
+			opts:   []Option{WithBodyHashPolicy(NeverBodyHash)},
+			method: http.MethodPost,
+			body:   "{}",
+			want:   "",
+		},
+		{
+			name:        "JSON body",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        "{}",
+			want:        `oauth_body_hash="RBNvo1WzZ4oRRq0W9%2BhknpT7T8If536DEMBg9hyq%2F4o%3D"`,
+		},
+		{
+			name:        "Form body",
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			body:        "a=1",
+			want:        "",
+		},
+		{
+			name:   "Custom policy with GET",
+			opts:   []Option{WithBodyHashPolicy(skipWithoutBody)},
+			method: http.MethodGet,
+			want:   "",
+		},
+		{
+			name:   "Custom policy with PUT",
+			opts:   []Option{WithBodyHashPolicy(skipWithoutBody)},
+			method: http.MethodPut,
+			body:   "{}",
+			want:   `oauth_body_hash="RBNvo1WzZ4oRRq0W9%2BhknpT7T8If536DEMBg9hyq%2F4o%3D"`,
+		},
+		{
+			name:    "Unavailable algorithm",
+			opts:    []Option{WithBodyHashAlgorithm(crypto.MD4)},
+			method:  http.MethodPost,
+			body:    "{}",
+			wantErr: ErrBodyHashUnavailable,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tC.method, "https://example.com/request", strings.NewReader(tC.body))
+
+			if tC.contentType != "" {
+				req.Header.Set("Content-Type", tC.contentType)
+			}
+
+			err := NewSigner(consumerKey, privateKey, tC.opts...).SignRequest(req)
+
+			assertResponseEquality(t, err, tC.wantErr)
+
+			got := req.Header.Get("Authorization")
+
+			if tC.want == "" && strings.Contains(got, "oauth_body_hash") {
+				t.Errorf("\ngot '%v'\nshould not contain oauth_body_hash", got)
+			}
+
+			if !strings.Contains(got, tC.want) {
+				t.Errorf("\ngot '%v'\nshould contain '%v'", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestIsFormContentType(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		want        bool
+	}{
+		{name: "Form", contentType: "application/x-www-form-urlencoded", want: true},
+		{name: "Form with charset", contentType: "Application/X-WWW-Form-Urlencoded; charset=UTF-8", want: true},
+		{name: "JSON", contentType: "application/json", want: false},
+		{name: "Multipart", contentType: "multipart/form-data; boundary=x", want: false},
+		{name: "Empty", contentType: "", want: false},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := isFormContentType(tC.contentType)
+
+			assertResponseEquality(t, got, tC.want)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package signer
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNoCertificate is returned by ParseCertificates when the data holds no certificate.
+var ErrNoCertificate = errors.New("signer: no X.509 certificate found")
+
+// Reasons for a request rejected by a CertificateVerifier.
+const (
+	ErrCertificateExpired     = VerifyError("signer: certificate has expired")
+	ErrCertificateNotYetValid = VerifyError("signer: certificate is not yet valid")
+	ErrUntrustedCertificate   = VerifyError("signer: certificate is not signed by a trusted authority")
+)
+
+// ParseCertificates parses PEM encoded "CERTIFICATE" blocks, or one or more DER
+// encoded certificates.
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	rest := data
+
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) > 0 {
+		return certs, nil
+	}
+
+	if len(rest) != len(data) {
+		return nil, ErrNoCertificate
+	}
+
+	certs, err := x509.ParseCertificates(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(certs) == 0 {
+		return nil, ErrNoCertificate
+	}
+
+	return certs, nil
+}
+
+// CertificateFingerprint returns the hex encoded SHA-256 hash of the DER
+// encoding of the certificate.
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// CertificateVerifier verifies requests against the RSA public keys of X.509
+// certificates, for instance the current and the next certificate of a consumer
+// while its signing key is rotated.
+type CertificateVerifier struct {
+	// Certificates are tried in order. Certificates without an RSA key are skipped.
+	Certificates []*x509.Certificate
+
+	// Roots, if set, are the authorities the matching certificate must chain to.
+	// Otherwise the certificate is trusted as is.
+	Roots *x509.CertPool
+
+	// Intermediates are used to build the chain to Roots.
+	Intermediates *x509.CertPool
+
+	// Clock tells the time the validity period is checked at. If nil, the system
+	// clock is used.
+	Clock Clock
+
+	// Options are passed to Verify.
+	Options *VerifyOptions
+}
+
+// Verify checks the request like the package function Verify, and returns the
+// fingerprint of the first certificate whose key signed it and which is valid at
+// the current time and, if Roots is set, trusted. Renewed certificates often keep
+// the key, so a matching certificate that fails these checks doesn't stop the
+// search; its error is returned only if no certificate passes.
+func (v *CertificateVerifier) Verify(req *http.Request) (string, error) {
+	s, err := parseSignedRequest(req, v.Options)
+
+	if err != nil {
+		return "", err
+	}
+
+	var certErr error
+
+	for _, cert := range v.Certificates {
+		publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+
+		if !ok || !s.verify(publicKey) {
+			continue
+		}
+
+		if err := v.checkCertificate(cert); err != nil {
+			if certErr == nil {
+				certErr = err
+			}
+
+			continue
+		}
+
+		return CertificateFingerprint(cert), nil
+	}
+
+	if certErr != nil {
+		return "", certErr
+	}
+
+	return "", ErrInvalidSignature
+}
+
+// checkCertificate checks the validity period of the certificate and its chain to Roots.
+func (v *CertificateVerifier) checkCertificate(cert *x509.Certificate) error {
+	now := v.now()
+
+	if now.Before(cert.NotBefore) {
+		return ErrCertificateNotYetValid
+	}
+
+	if now.After(cert.NotAfter) {
+		return ErrCertificateExpired
+	}
+
+	if v.Roots == nil {
+		return nil
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         v.Roots,
+		Intermediates: v.Intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	if err != nil {
+		return ErrUntrustedCertificate
+	}
+
+	return nil
+}
+
+func (v *CertificateVerifier) now() time.Time {
+	if v.Clock != nil {
+		return v.Clock.Now()
+	}
+
+	return time.Now()
+}
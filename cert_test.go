@@ -0,0 +1,240 @@
+package signer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const certFingerprint = "dd72d2105285fdfccd91f390b2b81474f2dcc69e6288671edb24fc40de06a6ed"
+
+func TestParseCertificates(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		data    string
+		wantLen int
+		wantErr error
+	}{
+		{name: "PEM", file: "testdata/rsa-cert.pem", wantLen: 1},
+		{name: "DER", file: "testdata/rsa-cert.der", wantLen: 1},
+		{name: "PEM key", file: "testdata/rsa-pkcs1.pem", wantErr: ErrNoCertificate},
+		{name: "Empty", data: "", wantErr: ErrNoCertificate},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := []byte(tC.data)
+
+			if tC.file != "" {
+				var err error
+
+				if data, err = ioutil.ReadFile(tC.file); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := ParseCertificates(data)
+
+			assertResponseEquality(t, err, tC.wantErr)
+			assertResponseEquality(t, len(got), tC.wantLen)
+
+			if len(got) > 0 {
+				assertResponseEquality(t, CertificateFingerprint(got[0]), certFingerprint)
+			}
+		})
+	}
+}
+
+func TestParseCertificatesInvalidDER(t *testing.T) {
+	if _, err := ParseCertificates([]byte("not a certificate")); err == nil {
+		t.Error("got nil error for invalid DER data")
+	}
+}
+
+// newTestCertificate creates a certificate for key, signed by the parent
+// certificate and its key, or self-signed if parent is nil.
+func newTestCertificate(t *testing.T, key *rsa.PrivateKey, parent *x509.Certificate, parentKey *rsa.PrivateKey, isCA bool) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "oauth1-signer-go test"},
+		NotBefore:             time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func TestCertificateVerifier(t *testing.T) {
+	keyPEM, err := ioutil.ReadFile("testdata/rsa-pkcs1.pem")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParsePrivateKey(keyPEM)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, err := ioutil.ReadFile("testdata/rsa-cert.pem")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certs, err := ParseCertificates(certPEM)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := certs[0]
+	otherCert := newTestCertificate(t, otherKey, nil, nil, false)
+	caCert := newTestCertificate(t, otherKey, nil, nil, true)
+	issuedCert := newTestCertificate(t, key, caCert, otherKey, false)
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(caCert)
+
+	valid := FixedClock(cert.NotBefore.Add(24 * time.Hour))
+	afterIssued := FixedClock(issuedCert.NotAfter.Add(time.Second))
+
+	testCases := []struct {
+		name            string
+		verifier        CertificateVerifier
+		wantFingerprint string
+		wantErr         error
+	}{
+		{
+			name:            "Valid",
+			verifier:        CertificateVerifier{Certificates: []*x509.Certificate{cert}, Clock: valid},
+			wantFingerprint: certFingerprint,
+		},
+		{
+			name:            "Second certificate",
+			verifier:        CertificateVerifier{Certificates: []*x509.Certificate{otherCert, cert}, Clock: valid},
+			wantFingerprint: certFingerprint,
+		},
+		{
+			name:     "Other key",
+			verifier: CertificateVerifier{Certificates: []*x509.Certificate{otherCert}, Clock: valid},
+			wantErr:  ErrInvalidSignature,
+		},
+		{
+			name:     "No certificates",
+			verifier: CertificateVerifier{Clock: valid},
+			wantErr:  ErrInvalidSignature,
+		},
+		{
+			name:     "Not yet valid",
+			verifier: CertificateVerifier{Certificates: []*x509.Certificate{cert}, Clock: FixedClock(cert.NotBefore.Add(-time.Second))},
+			wantErr:  ErrCertificateNotYetValid,
+		},
+		{
+			name:     "Expired",
+			verifier: CertificateVerifier{Certificates: []*x509.Certificate{cert}, Clock: FixedClock(cert.NotAfter.Add(time.Second))},
+			wantErr:  ErrCertificateExpired,
+		},
+		{
+			name:            "Expired before renewed",
+			verifier:        CertificateVerifier{Certificates: []*x509.Certificate{issuedCert, cert}, Clock: afterIssued},
+			wantFingerprint: certFingerprint,
+		},
+		{
+			name:            "Renewed before expired",
+			verifier:        CertificateVerifier{Certificates: []*x509.Certificate{cert, issuedCert}, Clock: afterIssued},
+			wantFingerprint: certFingerprint,
+		},
+		{
+			name:     "First failure",
+			verifier: CertificateVerifier{Certificates: []*x509.Certificate{issuedCert, cert}, Clock: FixedClock(cert.NotAfter.Add(time.Second))},
+			wantErr:  ErrCertificateExpired,
+		},
+		{
+			name:     "Not yet renewed",
+			verifier: CertificateVerifier{Certificates: []*x509.Certificate{cert, issuedCert}, Clock: FixedClock(issuedCert.NotBefore.Add(-time.Second))},
+			wantErr:  ErrCertificateNotYetValid,
+		},
+		{
+			name: "Trusted",
+			verifier: CertificateVerifier{
+				Certificates: []*x509.Certificate{issuedCert},
+				Roots:        trusted,
+				Clock:        FixedClock(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			wantFingerprint: CertificateFingerprint(issuedCert),
+		},
+		{
+			name: "Untrusted",
+			verifier: CertificateVerifier{
+				Certificates: []*x509.Certificate{cert},
+				Roots:        trusted,
+				Clock:        valid,
+			},
+			wantErr: ErrUntrustedCertificate,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(http.MethodPost, "https://example.com/service", strings.NewReader("{}"))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := NewSigner(consumerKey, key).SignRequest(req); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tC.verifier.Verify(req)
+
+			assertResponseEquality(t, err, tC.wantErr)
+			assertResponseEquality(t, got, tC.wantFingerprint)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// ErrCallbackNotConfirmed is returned when the server doesn't confirm the
+// oauth_callback of a temporary credentials request.
+var ErrCallbackNotConfirmed = errors.New("signer: server did not confirm oauth_callback")
+
+// ErrMissingSignatureMethod is returned when a request is signed without a signature method.
+var ErrMissingSignatureMethod = errors.New("signer: signature method is not set")
+
+// Credentials are a token and its shared secret, either temporary credentials
+// or token credentials.
+type Credentials struct {
+	Token  string
+	Secret string
+}
+
+// Client obtains token credentials with the three-legged OAuth 1.0a flow of
+// RFC 5849 section 2: temporary credentials, resource owner authorization and
+// token credentials.
+type Client struct {
+	// ConsumerKey identifies the client to the server.
+	ConsumerKey string
+
+	// Method signs the requests, e.g. HMACSHA1 with the consumer secret. It is required.
+	Method SignatureMethod
+
+	// Options are applied to every Signer created by the client.
+	Options []Option
+
+	// TemporaryCredentialRequestURI is the endpoint for temporary credentials.
+	TemporaryCredentialRequestURI string
+
+	// ResourceOwnerAuthorizationURI is where the resource owner is sent to grant access.
+	ResourceOwnerAuthorizationURI string
+
+	// TokenRequestURI is the endpoint for token credentials.
+	TokenRequestURI string
+
+	// HTTPClient sends the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// RequestTemporaryCredentials obtains temporary credentials. The server redirects
+// the resource owner to callbackURL after authorization; an empty callbackURL
+// means "oob", the verifier is then passed to the client out of band.
+func (c *Client) RequestTemporaryCredentials(callbackURL string) (*Credentials, error) {
+	if callbackURL == "" {
+		callbackURL = "oob"
+	}
+
+	params, err := c.requestCredentials(c.TemporaryCredentialRequestURI, nil, map[string]string{
+		"oauth_callback": callbackURL,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Get("oauth_callback_confirmed") != "true" {
+		return nil, ErrCallbackNotConfirmed
+	}
+
+	return credentialsFromParams(params)
+}
+
+// AuthorizationURL returns the URL the resource owner has to visit to authorize
+// the temporary credentials.
+func (c *Client) AuthorizationURL(temporaryCredentials *Credentials) (string, error) {
+	u, err := url.Parse(c.ResourceOwnerAuthorizationURI)
+
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set("oauth_token", temporaryCredentials.Token)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// RequestTokenCredentials exchanges authorized temporary credentials and the
+// verifier received from the resource owner for token credentials.
+func (c *Client) RequestTokenCredentials(temporaryCredentials *Credentials, verifier string) (*Credentials, error) {
+	params, err := c.requestCredentials(c.TokenRequestURI, temporaryCredentials, map[string]string{
+		"oauth_verifier": verifier,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return credentialsFromParams(params)
+}
+
+// Signer returns a Signer for requests on behalf of the resource owner. Pass nil
+// to sign requests with the consumer credentials only.
+func (c *Client) Signer(tokenCredentials *Credentials) *Signer {
+	opts := append([]Option{WithSignatureMethod(c.Method)}, c.Options...)
+
+	if tokenCredentials != nil {
+		opts = append(opts, WithToken(tokenCredentials.Token, tokenCredentials.Secret))
+	}
+
+	return NewSigner(c.ConsumerKey, nil, opts...)
+}
+
+// requestCredentials sends a signed POST request to a credentials endpoint and
+// returns the form encoded response.
+func (c *Client) requestCredentials(uri string, credentials *Credentials, extraParams map[string]string) (url.Values, error) {
+	if c.Method == nil {
+		return nil, ErrMissingSignatureMethod
+	}
+
+	s := c.Signer(credentials)
+
+	bodyHash, err := s.getBodyHash(http.MethodPost, "", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	authorizationHeader, err := s.authorizationHeader(uri, http.MethodPost, "", bodyHash, extraParams)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", authorizationHeader)
+
+	res, err := c.httpClient().Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signer: %s returned %s: %s", uri, res.Status, body)
+	}
+
+	return url.ParseQuery(string(body))
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return http.DefaultClient
+}
+
+func credentialsFromParams(params url.Values) (*Credentials, error) {
+	token := params.Get("oauth_token")
+
+	if token == "" {
+		return nil, errors.New("signer: oauth_token missing in server response")
+	}
+
+	return &Credentials{
+		Token:  token,
+		Secret: params.Get("oauth_token_secret"),
+	}, nil
+}
@@ -0,0 +1,264 @@
+package signer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testProvider is an OAuth 1.0a server with a single consumer and resource owner.
+type testProvider struct {
+	consumerKey    string
+	consumerSecret string
+
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+func (p *testProvider) checkSignature(r *http.Request, wantToken bool) (map[string]string, bool) {
+	header, err := ParseAuthorizationHeader(r.Header.Get("Authorization"))
+
+	if err != nil || header.Params["oauth_consumer_key"] != p.consumerKey {
+		return nil, false
+	}
+
+	oauthParams := header.Params
+
+	p.mu.Lock()
+	tokenSecret, ok := p.secrets[oauthParams["oauth_token"]]
+	p.mu.Unlock()
+
+	if wantToken != ok {
+		return nil, false
+	}
+
+	signature := oauthParams["oauth_signature"]
+	delete(oauthParams, "oauth_signature")
+
+	sbs, err := buildSignatureBaseString(requestURI(r, nil), r.Method, "", oauthParams)
+
+	if err != nil {
+		return nil, false
+	}
+
+	want, _ := HMACSHA1{ConsumerSecret: p.consumerSecret}.Sign(sbs, tokenSecret)
+
+	return oauthParams, signature == want
+}
+
+func (p *testProvider) issue(w http.ResponseWriter, token, secret string, extra string) {
+	p.mu.Lock()
+	p.secrets[token] = secret
+	p.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+	fmt.Fprintf(w, "oauth_token=%s&oauth_token_secret=%s%s", token, secret, extra)
+}
+
+func (p *testProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/initiate":
+		oauthParams, ok := p.checkSignature(r, false)
+
+		if !ok || oauthParams["oauth_callback"] != "https://client.example.net/cb?x=1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		p.issue(w, "hh5s93j4hdidpola", "hdhd0244k9j7ao03", "&oauth_callback_confirmed=true")
+	case "/authorize":
+		if r.URL.Query().Get("oauth_token") != "hh5s93j4hdidpola" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		http.Redirect(w, r, "https://client.example.net/cb?x=1&oauth_token=hh5s93j4hdidpola&oauth_verifier=hfdp7dh39dks9884", http.StatusFound)
+	case "/token":
+		oauthParams, ok := p.checkSignature(r, true)
+
+		if !ok || oauthParams["oauth_token"] != "hh5s93j4hdidpola" || oauthParams["oauth_verifier"] != "hfdp7dh39dks9884" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		p.issue(w, "nnch734d00sl2jdk", "pfkkdhi9sl3r4s00", "")
+	case "/photos":
+		oauthParams, ok := p.checkSignature(r, true)
+
+		if !ok || oauthParams["oauth_token"] != "nnch734d00sl2jdk" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprint(w, "vacation.jpg")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClientThreeLeggedFlow(t *testing.T) {
+	provider := &testProvider{
+		consumerKey:    "dpf43f3p2l4k3l03",
+		consumerSecret: "kd94hf93k423kf44",
+		secrets:        map[string]string{},
+	}
+
+	server := httptest.NewServer(provider)
+	defer server.Close()
+
+	c := &Client{
+		ConsumerKey:                   "dpf43f3p2l4k3l03",
+		Method:                        HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+		TemporaryCredentialRequestURI: server.URL + "/initiate",
+		ResourceOwnerAuthorizationURI: server.URL + "/authorize",
+		TokenRequestURI:               server.URL + "/token",
+	}
+
+	temporaryCredentials, err := c.RequestTemporaryCredentials("https://client.example.net/cb?x=1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertResponseEquality(t, *temporaryCredentials, Credentials{Token: "hh5s93j4hdidpola", Secret: "hdhd0244k9j7ao03"})
+
+	authorizationURL, err := c.AuthorizationURL(temporaryCredentials)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The resource owner approves in the browser and is redirected to the callback.
+	browser := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := browser.Get(authorizationURL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenCredentials, err := c.RequestTokenCredentials(temporaryCredentials, callback.Query().Get("oauth_verifier"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertResponseEquality(t, *tokenCredentials, Credentials{Token: "nnch734d00sl2jdk", Secret: "pfkkdhi9sl3r4s00"})
+
+	client := &http.Client{
+		Transport: &Transport{Signer: c.Signer(tokenCredentials)},
+	}
+
+	res, err = client.Get(server.URL + "/photos?file=vacation.jpg&size=original")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertResponseEquality(t, res.StatusCode, http.StatusOK)
+	assertResponseEquality(t, string(body), "vacation.jpg")
+}
+
+func TestClientRequestTemporaryCredentialsErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "Unauthorized",
+			status:  http.StatusUnauthorized,
+			body:    "oauth_problem=signature_invalid",
+			wantErr: "401 Unauthorized: oauth_problem=signature_invalid",
+		},
+		{
+			name:    "Callback not confirmed",
+			status:  http.StatusOK,
+			body:    "oauth_token=a&oauth_token_secret=b",
+			wantErr: ErrCallbackNotConfirmed.Error(),
+		},
+		{
+			name:    "Missing token",
+			status:  http.StatusOK,
+			body:    "oauth_callback_confirmed=true",
+			wantErr: "signer: oauth_token missing in server response",
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tC.status)
+				fmt.Fprint(w, tC.body)
+			}))
+			defer server.Close()
+
+			c := &Client{
+				ConsumerKey:                   "dpf43f3p2l4k3l03",
+				Method:                        HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+				TemporaryCredentialRequestURI: server.URL + "/initiate",
+			}
+
+			_, err := c.RequestTemporaryCredentials("")
+
+			if err == nil || !strings.HasSuffix(err.Error(), tC.wantErr) {
+				t.Errorf("\ngot error '%v'\nwant '%v'", err, tC.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientWithoutSignatureMethod(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request without a signature method")
+	}))
+	defer server.Close()
+
+	c := &Client{
+		ConsumerKey:                   "dpf43f3p2l4k3l03",
+		TemporaryCredentialRequestURI: server.URL + "/initiate",
+		TokenRequestURI:               server.URL + "/token",
+	}
+
+	_, err := c.RequestTemporaryCredentials("")
+
+	assertResponseEquality(t, err, ErrMissingSignatureMethod)
+
+	_, err = c.RequestTokenCredentials(&Credentials{Token: "a", Secret: "b"}, "verifier")
+
+	assertResponseEquality(t, err, ErrMissingSignatureMethod)
+
+	_, err = c.Signer(nil).AuthorizationHeader(server.URL, http.MethodGet, "")
+
+	assertResponseEquality(t, err, ErrMissingSignatureMethod)
+}
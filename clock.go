@@ -0,0 +1,75 @@
+package signer
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMissingDate is returned by SkewedClock.UpdateFromResponse when a response has no usable Date header.
+var ErrMissingDate = errors.New("signer: response has no valid Date header")
+
+// SkewedClock is a Clock corrected by an offset to the server time. The offset
+// can be set directly or learned from the Date header of server responses. It is
+// safe to share one SkewedClock between signers and goroutines. The zero value
+// is a SkewedClock on the system clock with no offset.
+type SkewedClock struct {
+	// offset is accessed atomically and must stay the first field for 64-bit alignment.
+	offset int64
+	base   Clock
+}
+
+// NewSkewedClock returns a SkewedClock on top of base with no offset. If base is
+// nil, the system clock is used.
+func NewSkewedClock(base Clock) *SkewedClock {
+	if base == nil {
+		base = systemClock{}
+	}
+
+	return &SkewedClock{base: base}
+}
+
+// Now returns the base clock time corrected by the offset.
+func (c *SkewedClock) Now() time.Time {
+	return c.baseNow().Add(c.Offset())
+}
+
+// baseNow returns the time of the base clock, or of the system clock if there is none.
+func (c *SkewedClock) baseNow() time.Time {
+	if c.base == nil {
+		return systemClock{}.Now()
+	}
+
+	return c.base.Now()
+}
+
+// Offset returns the current offset to the server time.
+func (c *SkewedClock) Offset() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.offset))
+}
+
+// SetOffset sets the offset to the server time.
+func (c *SkewedClock) SetOffset(offset time.Duration) {
+	atomic.StoreInt64(&c.offset, int64(offset))
+}
+
+// UpdateFromResponse learns the offset from the Date header of a server response.
+// As Date has a resolution of one second, the offset is only as accurate.
+func (c *SkewedClock) UpdateFromResponse(res *http.Response) error {
+	date := res.Header.Get("Date")
+
+	if date == "" {
+		return ErrMissingDate
+	}
+
+	serverTime, err := http.ParseTime(date)
+
+	if err != nil {
+		return ErrMissingDate
+	}
+
+	c.SetOffset(serverTime.Sub(c.baseNow()))
+
+	return nil
+}
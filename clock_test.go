@@ -0,0 +1,189 @@
+package signer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestSkewedClockUpdateFromResponse(t *testing.T) {
+	local := time.Date(2019, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		date       string
+		wantOffset time.Duration
+		wantErr    error
+	}{
+		{
+			name:       "Server ahead",
+			date:       "Wed, 16 Oct 2019 12:02:30 GMT",
+			wantOffset: 150 * time.Second,
+		},
+		{
+			name:       "Server behind",
+			date:       "Wed, 16 Oct 2019 11:59:15 GMT",
+			wantOffset: -45 * time.Second,
+		},
+		{
+			name:       "In sync",
+			date:       "Wed, 16 Oct 2019 12:00:00 GMT",
+			wantOffset: 0,
+		},
+		{
+			name:    "Missing Date",
+			date:    "",
+			wantErr: ErrMissingDate,
+		},
+		{
+			name:    "Invalid Date",
+			date:    "yesterday",
+			wantErr: ErrMissingDate,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := NewSkewedClock(&fakeClock{now: local})
+
+			res := &http.Response{Header: http.Header{}}
+
+			if tC.date != "" {
+				res.Header.Set("Date", tC.date)
+			}
+
+			err := clock.UpdateFromResponse(res)
+
+			assertResponseEquality(t, err, tC.wantErr)
+			assertResponseEquality(t, clock.Offset(), tC.wantOffset)
+			assertResponseEquality(t, clock.Now().Equal(local.Add(tC.wantOffset)), true)
+		})
+	}
+}
+
+func TestSkewedClockZeroValue(t *testing.T) {
+	var clock SkewedClock
+
+	before := time.Now()
+	got := clock.Now()
+
+	if got.Before(before) || got.After(time.Now()) {
+		t.Errorf("got '%v', want the system time", got)
+	}
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+
+	if err := clock.UpdateFromResponse(res); err != nil {
+		t.Fatal(err)
+	}
+
+	if offset := clock.Offset(); offset < 59*time.Minute || offset > time.Hour {
+		t.Errorf("got offset '%v', want about one hour", offset)
+	}
+}
+
+func TestSkewedClockCorrectsTimestamp(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := &fakeClock{now: time.Unix(1524771555, 0)}
+	clock := NewSkewedClock(base)
+	clock.SetOffset(-5 * time.Minute)
+
+	s := NewSigner(consumerKey, privateKey, WithClock(clock))
+
+	got, err := s.AuthorizationHeader("https://example.com/request", http.MethodGet, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `oauth_timestamp="1524771255"`
+
+	if !strings.Contains(got, want) {
+		t.Errorf("\ngot '%v'\nshould contain '%v'", got, want)
+	}
+
+	base.Advance(time.Minute)
+
+	assertResponseEquality(t, getTimestamp(clock), "1524771315")
+}
+
+func TestTransportLearnsSkew(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := time.Date(2019, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+	var timestamps []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header, err := ParseAuthorizationHeader(r.Header.Get("Authorization"))
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		timestamps = append(timestamps, header.Params["oauth_timestamp"])
+
+		w.Header().Set("Date", local.Add(10*time.Minute).Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	clock := NewSkewedClock(&fakeClock{now: local})
+
+	client := &http.Client{
+		Transport: &Transport{
+			Signer: NewSigner(consumerKey, privateKey, WithClock(clock)),
+			Skew:   clock,
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(server.URL)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res.Body.Close()
+	}
+
+	assertResponseEquality(t, clock.Offset(), 10*time.Minute)
+	assertResponseEquality(t, timestamps[0], "1571227200")
+	assertResponseEquality(t, timestamps[1], "1571227800")
+}
@@ -0,0 +1,289 @@
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha512" // SHA-512 OAEP padding
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// Errors returned by field level encryption.
+var (
+	ErrInvalidEncryptedField = errors.New("signer: invalid field level encryption data")
+	ErrUnsupportedOAEPHash   = errors.New("signer: OAEP hash must be SHA-256 or SHA-512")
+)
+
+// FieldValueEncoding is the encoding of binary field level encryption values.
+type FieldValueEncoding int
+
+const (
+	// HexFieldValueEncoding encodes values as lowercase hex. It is the default.
+	HexFieldValueEncoding FieldValueEncoding = iota
+
+	// Base64FieldValueEncoding encodes values as standard base64.
+	Base64FieldValueEncoding
+)
+
+func (e FieldValueEncoding) encode(data []byte) string {
+	if e == Base64FieldValueEncoding {
+		return base64.StdEncoding.EncodeToString(data)
+	}
+
+	return hex.EncodeToString(data)
+}
+
+func (e FieldValueEncoding) decode(s string) ([]byte, error) {
+	if e == Base64FieldValueEncoding {
+		return base64.StdEncoding.DecodeString(s)
+	}
+
+	return hex.DecodeString(s)
+}
+
+// fieldLevelKeySize is the size of the AES session keys of field level encryption.
+const fieldLevelKeySize = 16
+
+// FieldLevelEncryptionConfig encrypts and decrypts parts of JSON payloads with
+// the field level encryption scheme that Mastercard APIs used before JWE. Every
+// value is encrypted with a new AES-128-CBC session key, which is encrypted with
+// RSA-OAEP. The encrypted value, the encrypted key, the IV and the fingerprint
+// of the encryption key are stored as fields of one object. Paths are "$" for
+// the whole payload or "$.a.b" for a field.
+//
+// Field names default to the ones Mastercard uses. A field name of "-" leaves
+// the optional fingerprint and OAEP algorithm fields out.
+type FieldLevelEncryptionConfig struct {
+	// EncryptionKey is the public key request fields are encrypted for.
+	EncryptionKey *rsa.PublicKey
+
+	// EncryptionKeyFingerprint is sent in the fingerprint field. If empty,
+	// PublicKeyFingerprint of EncryptionKey is used.
+	EncryptionKeyFingerprint string
+
+	// DecryptionKey decrypts response fields.
+	DecryptionKey *rsa.PrivateKey
+
+	// EncryptionPaths map the path of each value to encrypt to the path of the
+	// object its encrypted fields are stored in.
+	EncryptionPaths map[string]string
+
+	// DecryptionPaths map the path of each object holding encrypted fields to
+	// the path its decrypted value is stored at.
+	DecryptionPaths map[string]string
+
+	// OAEPHash is the hash of the RSA-OAEP padding, crypto.SHA256 or
+	// crypto.SHA512. If zero, crypto.SHA256 is used. When decrypting, the OAEP
+	// algorithm field of the payload takes precedence.
+	OAEPHash crypto.Hash
+
+	// Encoding of the encrypted value, the encrypted key and the IV.
+	Encoding FieldValueEncoding
+
+	// Field names. If empty, "encryptedValue", "encryptedKey", "iv",
+	// "publicKeyFingerprint" and "oaepHashingAlgorithm" are used.
+	EncryptedValueFieldName       string
+	EncryptedKeyFieldName         string
+	IVFieldName                   string
+	PublicKeyFingerprintFieldName string
+	OAEPHashingAlgorithmFieldName string
+}
+
+// EncryptPayload encrypts the values at EncryptionPaths. Paths missing from the
+// payload are skipped, and without EncryptionPaths the payload is returned as is.
+func (c *FieldLevelEncryptionConfig) EncryptPayload(payload []byte) ([]byte, error) {
+	if len(c.EncryptionPaths) == 0 {
+		return payload, nil
+	}
+
+	if c.EncryptionKey == nil {
+		return nil, ErrMissingEncryptionKey
+	}
+
+	fingerprint := c.EncryptionKeyFingerprint
+
+	if fingerprint == "" {
+		var err error
+
+		if fingerprint, err = PublicKeyFingerprint(c.EncryptionKey); err != nil {
+			return nil, err
+		}
+	}
+
+	hash := c.oaepHash()
+
+	if hash != crypto.SHA256 && hash != crypto.SHA512 {
+		return nil, ErrUnsupportedOAEPHash
+	}
+
+	return encryptPaths(payload, c.EncryptionPaths, func(plaintext []byte) (map[string]interface{}, error) {
+		key, err := generateRandomBytes(fieldLevelKeySize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		iv, err := generateRandomBytes(aes.BlockSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		encryptedKey, err := rsa.EncryptOAEP(hash.New(), rand.Reader, c.EncryptionKey, key, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		block, err := aes.NewCipher(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+		encrypted := append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+		fields := map[string]interface{}{
+			c.fieldName(c.EncryptedValueFieldName, "encryptedValue"): c.Encoding.encode(encrypted),
+			c.fieldName(c.EncryptedKeyFieldName, "encryptedKey"):     c.Encoding.encode(encryptedKey),
+			c.fieldName(c.IVFieldName, "iv"):                         c.Encoding.encode(iv),
+		}
+
+		if name := c.fieldName(c.PublicKeyFingerprintFieldName, "publicKeyFingerprint"); name != "-" {
+			fields[name] = fingerprint
+		}
+
+		if name := c.fieldName(c.OAEPHashingAlgorithmFieldName, "oaepHashingAlgorithm"); name != "-" {
+			fields[name] = oaepHashName(hash)
+		}
+
+		return fields, nil
+	})
+}
+
+// DecryptPayload decrypts the values at DecryptionPaths. Paths missing from the
+// payload are skipped, and without DecryptionPaths the payload is returned as is.
+func (c *FieldLevelEncryptionConfig) DecryptPayload(payload []byte) ([]byte, error) {
+	if len(c.DecryptionPaths) == 0 {
+		return payload, nil
+	}
+
+	if c.DecryptionKey == nil {
+		return nil, ErrMissingDecryptionKey
+	}
+
+	return decryptPaths(payload, c.DecryptionPaths, func(obj map[string]interface{}) ([]byte, bool, error) {
+		valueField := c.fieldName(c.EncryptedValueFieldName, "encryptedValue")
+		keyField := c.fieldName(c.EncryptedKeyFieldName, "encryptedKey")
+		ivField := c.fieldName(c.IVFieldName, "iv")
+		fingerprintField := c.fieldName(c.PublicKeyFingerprintFieldName, "publicKeyFingerprint")
+		oaepField := c.fieldName(c.OAEPHashingAlgorithmFieldName, "oaepHashingAlgorithm")
+
+		value, ok := obj[valueField].(string)
+
+		if !ok {
+			return nil, false, nil
+		}
+
+		encryptedKey, _ := obj[keyField].(string)
+		iv, _ := obj[ivField].(string)
+		hash := c.oaepHash()
+
+		if name, ok := obj[oaepField].(string); ok {
+			if hash, ok = parseOAEPHashName(name); !ok {
+				return nil, true, ErrUnsupportedOAEPHash
+			}
+		}
+
+		for _, name := range []string{valueField, keyField, ivField, fingerprintField, oaepField} {
+			delete(obj, name)
+		}
+
+		plaintext, err := c.decrypt(value, encryptedKey, iv, hash)
+
+		return plaintext, true, err
+	})
+}
+
+// decrypt decodes and decrypts an encrypted value with its encrypted session key and IV.
+func (c *FieldLevelEncryptionConfig) decrypt(value, encryptedKey, iv string, hash crypto.Hash) ([]byte, error) {
+	var decoded [3][]byte
+
+	for i, s := range []string{value, encryptedKey, iv} {
+		var err error
+
+		if decoded[i], err = c.Encoding.decode(s); err != nil {
+			return nil, ErrInvalidEncryptedField
+		}
+	}
+
+	key, err := rsa.DecryptOAEP(hash.New(), rand.Reader, c.DecryptionKey, decoded[1], nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, ErrInvalidEncryptedField
+	}
+
+	data := decoded[0]
+
+	if len(decoded[2]) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, ErrInvalidEncryptedField
+	}
+
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, decoded[2]).CryptBlocks(decrypted, data)
+
+	if decrypted, err = unpad(decrypted, aes.BlockSize); err != nil {
+		return nil, ErrInvalidEncryptedField
+	}
+
+	return decrypted, nil
+}
+
+func (c *FieldLevelEncryptionConfig) oaepHash() crypto.Hash {
+	if c.OAEPHash != 0 {
+		return c.OAEPHash
+	}
+
+	return crypto.SHA256
+}
+
+func (c *FieldLevelEncryptionConfig) fieldName(name, defaultName string) string {
+	if name != "" {
+		return name
+	}
+
+	return defaultName
+}
+
+// oaepHashName returns the name of an OAEP hash in the oaepHashingAlgorithm field.
+func oaepHashName(hash crypto.Hash) string {
+	if hash == crypto.SHA512 {
+		return "SHA512"
+	}
+
+	return "SHA256"
+}
+
+func parseOAEPHashName(name string) (crypto.Hash, bool) {
+	switch name {
+	case "SHA256", "SHA-256":
+		return crypto.SHA256, true
+	case "SHA512", "SHA-512":
+		return crypto.SHA512, true
+	}
+
+	return 0, false
+}
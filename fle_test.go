@@ -0,0 +1,305 @@
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFieldLevelEncryptionConfigPayload(t *testing.T) {
+	key := testEncryptionKey(t)
+
+	fingerprint, err := PublicKeyFingerprint(&key.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name       string
+		config     FieldLevelEncryptionConfig
+		payload    string
+		encrypted  []string
+		wantFields map[string]string
+	}{
+		{
+			name: "Field",
+			config: FieldLevelEncryptionConfig{
+				EncryptionPaths: map[string]string{"$.path.to.foo": "$.path.to.encryptedFoo"},
+				DecryptionPaths: map[string]string{"$.path.to.encryptedFoo": "$.path.to.foo"},
+			},
+			payload:   `{"path":{"to":{"foo":{"sensitive":"this is a secret!"}}}}`,
+			encrypted: []string{"path", "to", "encryptedFoo"},
+			wantFields: map[string]string{
+				"publicKeyFingerprint": fingerprint,
+				"oaepHashingAlgorithm": "SHA256",
+			},
+		},
+		{
+			name: "Whole payload with SHA-512 and base64",
+			config: FieldLevelEncryptionConfig{
+				EncryptionPaths: map[string]string{"$": "$"},
+				DecryptionPaths: map[string]string{"$": "$"},
+				OAEPHash:        crypto.SHA512,
+				Encoding:        Base64FieldValueEncoding,
+			},
+			payload: `{"sensitive":"this is a secret!","amount":12.50}`,
+			wantFields: map[string]string{
+				"oaepHashingAlgorithm": "SHA512",
+			},
+		},
+		{
+			name: "Custom field names",
+			config: FieldLevelEncryptionConfig{
+				EncryptionPaths:               map[string]string{"$.card": "$.card"},
+				DecryptionPaths:               map[string]string{"$.card": "$.card"},
+				EncryptionKeyFingerprint:      "761b003c1eade3a5490e5000d37887baa5e6ec0e226c07706e599451fc032a79",
+				EncryptedValueFieldName:       "encryptedData",
+				EncryptedKeyFieldName:         "encryptedSessionKey",
+				IVFieldName:                   "initVector",
+				PublicKeyFingerprintFieldName: "keyFingerprint",
+				OAEPHashingAlgorithmFieldName: "-",
+			},
+			payload:   `{"card":"5123456789012345","holder":"visible"}`,
+			encrypted: []string{"card"},
+			wantFields: map[string]string{
+				"keyFingerprint": "761b003c1eade3a5490e5000d37887baa5e6ec0e226c07706e599451fc032a79",
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := tC.config
+			c.EncryptionKey = &key.PublicKey
+			c.DecryptionKey = key
+
+			encrypted, err := c.EncryptPayload([]byte(tC.payload))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(string(encrypted), "secret") || strings.Contains(string(encrypted), "5123456789012345") {
+				t.Errorf("got plaintext in '%s'", encrypted)
+			}
+
+			var v interface{}
+
+			if err := json.Unmarshal(encrypted, &v); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, field := range tC.encrypted {
+				v = v.(map[string]interface{})[field]
+			}
+
+			obj, _ := v.(map[string]interface{})
+
+			for name, want := range tC.wantFields {
+				assertResponseEquality(t, obj[name], want)
+			}
+
+			if _, ok := obj["-"]; ok {
+				t.Errorf("got field '-' in '%s'", encrypted)
+			}
+
+			decrypted, err := c.DecryptPayload(encrypted)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertJSONEquality(t, string(decrypted), tC.payload)
+		})
+	}
+}
+
+func TestFieldLevelEncryptionConfigDecrypt(t *testing.T) {
+	key := testEncryptionKey(t)
+
+	// Encrypt "{\"sensitive\":\"value\"}" the way the API does, independently of EncryptPayload.
+	sessionKey := bytes.Repeat([]byte{0x42}, 16)
+	iv := bytes.Repeat([]byte{0x24}, 16)
+	plaintext := []byte(`{"sensitive":"value"}`)
+	padded := append(plaintext, bytes.Repeat([]byte{11}, 11)...)
+
+	block, err := aes.NewCipher(sessionKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, sessionKey, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := `{"data":{"encryptedValue":"` + hex.EncodeToString(ciphertext) +
+		`","encryptedKey":"` + hex.EncodeToString(encryptedKey) +
+		`","iv":"` + hex.EncodeToString(iv) +
+		`","oaepHashingAlgorithm":"SHA256","publicKeyFingerprint":"abc"},"id":7}`
+
+	testCases := []struct {
+		name    string
+		payload string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "Valid",
+			payload: payload,
+			want:    `{"data":{"sensitive":"value"},"id":7}`,
+		},
+		{
+			name:    "Unsupported OAEP hash",
+			payload: strings.Replace(payload, `"SHA256"`, `"SHA1"`, 1),
+			wantErr: ErrUnsupportedOAEPHash,
+		},
+		{
+			name:    "Invalid hex",
+			payload: strings.Replace(payload, `"iv":"`, `"iv":"zz`, 1),
+			wantErr: ErrInvalidEncryptedField,
+		},
+		{
+			name:    "Truncated value",
+			payload: strings.Replace(payload, hex.EncodeToString(ciphertext), hex.EncodeToString(ciphertext[:20]), 1),
+			wantErr: ErrInvalidEncryptedField,
+		},
+		{
+			name:    "Not encrypted",
+			payload: `{"data":{"visible":true}}`,
+			want:    `{"data":{"visible":true}}`,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &FieldLevelEncryptionConfig{
+				DecryptionKey:   key,
+				DecryptionPaths: map[string]string{"$.data": "$.data"},
+			}
+
+			got, err := c.DecryptPayload([]byte(tC.payload))
+
+			assertResponseEquality(t, err, tC.wantErr)
+
+			if err == nil {
+				assertJSONEquality(t, string(got), tC.want)
+			}
+		})
+	}
+}
+
+func TestFieldLevelEncryptionConfigErrors(t *testing.T) {
+	key := testEncryptionKey(t)
+
+	testCases := []struct {
+		name    string
+		config  FieldLevelEncryptionConfig
+		wantErr error
+	}{
+		{
+			name:    "Missing encryption key",
+			config:  FieldLevelEncryptionConfig{EncryptionPaths: map[string]string{"$": "$"}},
+			wantErr: ErrMissingEncryptionKey,
+		},
+		{
+			name: "Unsupported OAEP hash",
+			config: FieldLevelEncryptionConfig{
+				EncryptionKey:   &key.PublicKey,
+				EncryptionPaths: map[string]string{"$": "$"},
+				OAEPHash:        crypto.SHA1,
+			},
+			wantErr: ErrUnsupportedOAEPHash,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tC.config.EncryptPayload([]byte(`{}`))
+
+			assertResponseEquality(t, err, tC.wantErr)
+		})
+	}
+}
+
+func TestTransportFieldLevelEncryption(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := testEncryptionKey(t)
+
+	var gotBody []byte
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The body hash must cover the encrypted JSON that was sent.
+		if err := Verify(r, &privateKey.PublicKey, nil); err != nil {
+			t.Error(err)
+		}
+
+		gotBody, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			Signer: NewSigner(consumerKey, privateKey),
+			Encryption: &FieldLevelEncryptionConfig{
+				EncryptionKey:   &key.PublicKey,
+				EncryptionPaths: map[string]string{"$.card": "$.encryptedCard"},
+			},
+		},
+	}
+
+	res, err := client.Post(ts.URL, "application/json", strings.NewReader(`{"card":{"number":"5123456789012345"}}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	server := &FieldLevelEncryptionConfig{
+		DecryptionKey:   key,
+		DecryptionPaths: map[string]string{"$.encryptedCard": "$.card"},
+	}
+
+	decrypted, err := server.DecryptPayload(gotBody)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertJSONEquality(t, string(decrypted), `{"card":{"number":"5123456789012345"}}`)
+}
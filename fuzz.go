@@ -0,0 +1,28 @@
+//go:build gofuzz
+// +build gofuzz
+
+package signer
+
+import "reflect"
+
+// Fuzz is the go-fuzz entry point for ParseAuthorizationHeader. Every header
+// that parses must print as a header that parses back to the same value.
+func Fuzz(data []byte) int {
+	header, err := ParseAuthorizationHeader(string(data))
+
+	if err != nil {
+		return 0
+	}
+
+	got, err := ParseAuthorizationHeader(header.String())
+
+	if err != nil {
+		panic(err)
+	}
+
+	if !reflect.DeepEqual(got, header) {
+		panic("signer: authorization header does not round-trip: " + header.String())
+	}
+
+	return 1
+}
@@ -0,0 +1,84 @@
+package signer
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestAuthorizationHeaderGolden(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		uri     string
+		method  string
+		payload string
+		nonce   string
+		time    time.Time
+	}{
+		{
+			name:    "get-with-query",
+			uri:     "HTTPS://SANDBOX.api.mastercard.com/merchantid/v1/merchantid?MerchantId=GOOGLE%20LTD%20ADWORDS%20%28CC%40GOOGLE.COM%29&Type=ExactMatch&Format=JSON",
+			method:  http.MethodGet,
+			payload: "",
+			nonce:   "uTeLPs6K",
+			time:    time.Unix(1524771555, 0),
+		},
+		{
+			name:    "post-json",
+			uri:     "https://sandbox.api.mastercard.com/service",
+			method:  http.MethodPost,
+			payload: `{"amount":100,"currency":"EUR"}`,
+			nonce:   "xZ9aB3kQ",
+			time:    time.Unix(1571234567, 0),
+		},
+		{
+			name:    "put-with-encoded-query",
+			uri:     "https://sandbox.api.mastercard.com/service/a%20b?q=a+b&name=Jos%C3%A9&flag",
+			method:  http.MethodPut,
+			payload: "Hello world!",
+			nonce:   "n0nce",
+			time:    time.Unix(1600000000, 0),
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			s := NewSigner(consumerKey, privateKey, WithNonceSource(FixedNonce(tC.nonce)), WithClock(FixedClock(tC.time)))
+
+			got, err := s.AuthorizationHeader(tC.uri, tC.method, tC.payload)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "golden", tC.name+".golden")
+
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := ioutil.ReadFile(golden)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertResponseEquality(t, got, string(want))
+		})
+	}
+}
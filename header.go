@@ -0,0 +1,182 @@
+package signer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// AuthorizationHeader is an OAuth Authorization header as described in RFC 5849
+// section 3.5.1.
+type AuthorizationHeader struct {
+	// Realm is the optional realm parameter. It is not part of the signature and
+	// is left out of the header if empty.
+	Realm string
+
+	// Params are the decoded protocol parameters, such as oauth_consumer_key. They
+	// must not contain realm.
+	Params map[string]string
+}
+
+// String returns the header value. The realm comes first as a quoted string,
+// followed by the percent-encoded parameters sorted by name.
+func (h AuthorizationHeader) String() string {
+	var b strings.Builder
+
+	b.WriteString("OAuth ")
+
+	if h.Realm != "" {
+		b.WriteString(`realm="`)
+		b.WriteString(quoteEscaper.Replace(h.Realm))
+		b.WriteString(`"`)
+
+		if len(h.Params) > 0 {
+			b.WriteString(",")
+		}
+	}
+
+	for i, k := range getSortedKeys(h.Params) {
+		if i > 0 {
+			b.WriteString(",")
+		}
+
+		b.WriteString(percentEncode(k) + `="` + percentEncode(h.Params[k]) + `"`)
+	}
+
+	return b.String()
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// ParseAuthorizationHeader parses the value of an OAuth Authorization header.
+// Parameter names and values other than the realm are percent-decoded. It returns
+// ErrMissingAuthorization for an empty header and ErrMalformedAuthorization if the
+// header uses another scheme, is not well formed or repeats a parameter.
+func ParseAuthorizationHeader(header string) (*AuthorizationHeader, error) {
+	if header == "" {
+		return nil, ErrMissingAuthorization
+	}
+
+	const scheme = "OAuth"
+
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) || !isSpace(header[len(scheme)]) {
+		return nil, ErrMalformedAuthorization
+	}
+
+	h := &AuthorizationHeader{Params: map[string]string{}}
+	hasRealm := false
+	s := header[len(scheme):]
+
+	for {
+		s = trimSpace(s)
+
+		if s == "" {
+			return h, nil
+		}
+
+		// Empty list elements are allowed, as in "a, , b".
+		if s[0] == ',' {
+			s = s[1:]
+			continue
+		}
+
+		var name, value string
+		var ok bool
+
+		name, s = headerToken(s)
+		s = trimSpace(s)
+
+		if name == "" || s == "" || s[0] != '=' {
+			return nil, ErrMalformedAuthorization
+		}
+
+		value, s, ok = unquote(trimSpace(s[1:]))
+
+		if !ok {
+			return nil, ErrMalformedAuthorization
+		}
+
+		s = trimSpace(s)
+
+		if s != "" && s[0] != ',' {
+			return nil, ErrMalformedAuthorization
+		}
+
+		name, err := url.PathUnescape(name)
+
+		if err != nil {
+			return nil, ErrMalformedAuthorization
+		}
+
+		if strings.EqualFold(name, "realm") {
+			if hasRealm {
+				return nil, ErrMalformedAuthorization
+			}
+
+			h.Realm, hasRealm = value, true
+
+			continue
+		}
+
+		if value, err = url.PathUnescape(value); err != nil {
+			return nil, ErrMalformedAuthorization
+		}
+
+		if _, ok := h.Params[name]; ok {
+			return nil, ErrMalformedAuthorization
+		}
+
+		h.Params[name] = value
+	}
+}
+
+// headerToken splits a parameter name off the start of s.
+func headerToken(s string) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return r == '=' || r == ',' || r == '"' || r < 0x80 && isSpace(byte(r))
+	})
+
+	if i < 0 {
+		return s, ""
+	}
+
+	return s[:i], s[i:]
+}
+
+// unquote splits a quoted string off the start of s and returns its content with
+// quoted pairs resolved.
+func unquote(s string) (string, string, bool) {
+	if s == "" || s[0] != '"' {
+		return "", "", false
+	}
+
+	var b strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), s[i+1:], true
+		case '\\':
+			i++
+
+			if i == len(s) {
+				return "", "", false
+			}
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return "", "", false
+}
+
+func trimSpace(s string) string {
+	for s != "" && isSpace(s[0]) {
+		s = s[1:]
+	}
+
+	return s
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
@@ -0,0 +1,199 @@
+package signer
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func TestAuthorizationHeaderString(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header AuthorizationHeader
+		want   string
+	}{
+		{
+			name: "Simple",
+			header: AuthorizationHeader{
+				Params: map[string]string{
+					"oauth_body_hash":        "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+					"oauth_consumer_key":     "aaa!aaa",
+					"oauth_nonce":            "oauth_nonce",
+					"oauth_signature":        "Q/AnafnIfOC67BsVkQl9dQlRJeOzfSFUi6YugxLhAXasNyyAmZiXPkU5r8zZnuCg2NE8sqG9Jj0zMTY/vFbxhSQOaZs0ogpcJUE0CvWuMVzmgY/Dxv5XfjdZMfXVItkFkoaAs2GRryNd4fb26UekyX3JTHZpY+HJdUFjwrDM3q0=",
+					"oauth_signature_method": "RSA-SHA256",
+					"oauth_timestamp":        "oauth_timestamp",
+					"oauth_version":          "1.0",
+				},
+			},
+			want: `OAuth oauth_body_hash="47DEQpj8HBSa%2B%2FTImW%2B5JCeuQeRkm5NMpJWZG3hSuFU%3D",oauth_consumer_key="aaa%21aaa",oauth_nonce="oauth_nonce",oauth_signature="Q%2FAnafnIfOC67BsVkQl9dQlRJeOzfSFUi6YugxLhAXasNyyAmZiXPkU5r8zZnuCg2NE8sqG9Jj0zMTY%2FvFbxhSQOaZs0ogpcJUE0CvWuMVzmgY%2FDxv5XfjdZMfXVItkFkoaAs2GRryNd4fb26UekyX3JTHZpY%2BHJdUFjwrDM3q0%3D",oauth_signature_method="RSA-SHA256",oauth_timestamp="oauth_timestamp",oauth_version="1.0"`,
+		},
+		{
+			name: "RFC example with realm",
+			header: AuthorizationHeader{
+				Realm: "Example",
+				Params: map[string]string{
+					"oauth_consumer_key":     "0685bd9184jfhq22",
+					"oauth_signature_method": "HMAC-SHA1",
+				},
+			},
+			want: `OAuth realm="Example",oauth_consumer_key="0685bd9184jfhq22",oauth_signature_method="HMAC-SHA1"`,
+		},
+		{
+			name: "Realm with quotes",
+			header: AuthorizationHeader{
+				Realm: `say "hi", \o/`,
+			},
+			want: `OAuth realm="say \"hi\", \\o/"`,
+		},
+		{
+			name: "Encoded name",
+			header: AuthorizationHeader{
+				Params: map[string]string{"a b": `"c"`},
+			},
+			want: `OAuth a%20b="%22c%22"`,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			assertResponseEquality(t, tC.header.String(), tC.want)
+		})
+	}
+}
+
+func TestParseAuthorizationHeader(t *testing.T) {
+	testCases := []struct {
+		name    string
+		header  string
+		want    *AuthorizationHeader
+		wantErr error
+	}{
+		{
+			name:   "RFC example",
+			header: "OAuth realm=\"Example\", oauth_consumer_key=\"0685bd9184jfhq22\",\toauth_signature=\"wOJIO9A2W5mFwDgiDvZbTSMK%2FPY%3D\"",
+			want: &AuthorizationHeader{
+				Realm: "Example",
+				Params: map[string]string{
+					"oauth_consumer_key": "0685bd9184jfhq22",
+					"oauth_signature":    "wOJIO9A2W5mFwDgiDvZbTSMK/PY=",
+				},
+			},
+		},
+		{
+			name:   "Case-insensitive scheme and spaces",
+			header: `oauth  a = "1" ,, b="%20" ,`,
+			want: &AuthorizationHeader{
+				Params: map[string]string{"a": "1", "b": " "},
+			},
+		},
+		{
+			name:   "Quoted pairs in realm",
+			header: `OAuth realm="say \"hi\", \\o/",a="1"`,
+			want: &AuthorizationHeader{
+				Realm:  `say "hi", \o/`,
+				Params: map[string]string{"a": "1"},
+			},
+		},
+		{
+			name:   "No parameters",
+			header: "OAuth ",
+			want:   &AuthorizationHeader{Params: map[string]string{}},
+		},
+		{name: "Empty", header: "", wantErr: ErrMissingAuthorization},
+		{name: "Other scheme", header: "Basic ZGV2OnNlY3JldA==", wantErr: ErrMalformedAuthorization},
+		{name: "Scheme prefix", header: `OAuthx a="1"`, wantErr: ErrMalformedAuthorization},
+		{name: "Scheme only", header: "OAuth", wantErr: ErrMalformedAuthorization},
+		{name: "Unquoted value", header: `OAuth a=1`, wantErr: ErrMalformedAuthorization},
+		{name: "Unterminated value", header: `OAuth a="1`, wantErr: ErrMalformedAuthorization},
+		{name: "Missing value", header: `OAuth a`, wantErr: ErrMalformedAuthorization},
+		{name: "Missing name", header: `OAuth ="1"`, wantErr: ErrMalformedAuthorization},
+		{name: "Missing comma", header: `OAuth a="1" b="2"`, wantErr: ErrMalformedAuthorization},
+		{name: "Invalid escape", header: `OAuth a="%2"`, wantErr: ErrMalformedAuthorization},
+		{name: "Duplicate parameter", header: `OAuth a="1",a="2"`, wantErr: ErrMalformedAuthorization},
+		{name: "Duplicate encoded parameter", header: `OAuth a="1",%61="1"`, wantErr: ErrMalformedAuthorization},
+		{name: "Duplicate realm", header: `OAuth realm="a",Realm="b"`, wantErr: ErrMalformedAuthorization},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseAuthorizationHeader(tC.header)
+
+			assertResponseEquality(t, err, tC.wantErr)
+
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("\ngot '%#v'\nwant '%#v'", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizationHeaderRoundTrip(t *testing.T) {
+	f := func(realm string, params map[string]string) bool {
+		for k := range params {
+			if k == "" || strings.EqualFold(k, "realm") {
+				delete(params, k)
+			}
+		}
+
+		if params == nil {
+			params = map[string]string{}
+		}
+
+		want := &AuthorizationHeader{Realm: realm, Params: params}
+
+		got, err := ParseAuthorizationHeader(want.String())
+
+		if err != nil {
+			t.Logf("%q: %v", want.String(), err)
+			return false
+		}
+
+		return reflect.DeepEqual(got, want)
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSignerWithRealm(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uri := "https://example.com/request"
+
+	s := NewSigner(consumerKey, privateKey, WithRealm("Example"))
+
+	got, err := s.AuthorizationHeader(uri, http.MethodGet, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `OAuth realm="Example",oauth_body_hash=`; !strings.HasPrefix(got, want) {
+		t.Errorf("\ngot '%v'\nshould start with '%v'", got, want)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Authorization", got)
+
+	assertResponseEquality(t, Verify(req, &privateKey.PublicKey, nil), nil)
+}
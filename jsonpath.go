@@ -0,0 +1,145 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidJSONPath is returned for JSON paths other than "$" or "$.a.b".
+var ErrInvalidJSONPath = errors.New("signer: invalid JSON path")
+
+// ErrTrailingJSON is returned for payloads with data after the JSON value.
+var ErrTrailingJSON = errors.New("signer: unexpected data after JSON payload")
+
+// jsonPath is a parsed JSON path of the form "$" or "$.a.b", which are the only
+// forms payload encryption needs. The root path has no segments.
+type jsonPath []string
+
+func parseJSONPath(path string) (jsonPath, error) {
+	if path == "$" {
+		return jsonPath{}, nil
+	}
+
+	if !strings.HasPrefix(path, "$.") {
+		return nil, ErrInvalidJSONPath
+	}
+
+	segments := strings.Split(path[2:], ".")
+
+	for _, s := range segments {
+		if s == "" {
+			return nil, ErrInvalidJSONPath
+		}
+	}
+
+	return segments, nil
+}
+
+// decodeJSON decodes a payload, keeping numbers as written.
+func decodeJSON(payload []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(payload))
+	d.UseNumber()
+
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if err := d.Decode(&struct{}{}); err != io.EOF {
+		return nil, ErrTrailingJSON
+	}
+
+	return v, nil
+}
+
+// get returns the value at the path, if there is one.
+func (p jsonPath) get(root interface{}) (interface{}, bool) {
+	v := root
+
+	for _, s := range p {
+		obj, ok := v.(map[string]interface{})
+
+		if !ok {
+			return nil, false
+		}
+
+		if v, ok = obj[s]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// remove deletes the value at the path and returns the new root, which is nil
+// if the path is the root.
+func (p jsonPath) remove(root interface{}) interface{} {
+	if len(p) == 0 {
+		return nil
+	}
+
+	if parent, ok := p[:len(p)-1].get(root); ok {
+		if obj, ok := parent.(map[string]interface{}); ok {
+			delete(obj, p[len(p)-1])
+		}
+	}
+
+	return root
+}
+
+// set stores the value at the path, creating missing objects on the way, and
+// returns the new root. An object stored over an existing object is merged into
+// it.
+func (p jsonPath) set(root, value interface{}) (interface{}, error) {
+	if len(p) == 0 {
+		return merge(root, value), nil
+	}
+
+	if root == nil {
+		root = map[string]interface{}{}
+	}
+
+	obj, ok := root.(map[string]interface{})
+
+	if !ok {
+		return nil, ErrInvalidJSONPath
+	}
+
+	for _, s := range p[:len(p)-1] {
+		next, ok := obj[s]
+
+		if !ok {
+			next = map[string]interface{}{}
+			obj[s] = next
+		}
+
+		if obj, ok = next.(map[string]interface{}); !ok {
+			return nil, ErrInvalidJSONPath
+		}
+	}
+
+	last := p[len(p)-1]
+	obj[last] = merge(obj[last], value)
+
+	return root, nil
+}
+
+// merge returns value, with the fields of an existing object added if both are objects.
+func merge(existing, value interface{}) interface{} {
+	existingObj, ok := existing.(map[string]interface{})
+	valueObj, isObj := value.(map[string]interface{})
+
+	if !ok || !isObj {
+		return value
+	}
+
+	for k, v := range valueObj {
+		existingObj[k] = v
+	}
+
+	return existingObj
+}
@@ -0,0 +1,105 @@
+package signer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	testCases := []struct {
+		name    string
+		path    string
+		want    jsonPath
+		wantErr error
+	}{
+		{name: "Root", path: "$", want: jsonPath{}},
+		{name: "Field", path: "$.a", want: jsonPath{"a"}},
+		{name: "Nested field", path: "$.a.b", want: jsonPath{"a", "b"}},
+		{name: "Missing root", path: "a.b", wantErr: ErrInvalidJSONPath},
+		{name: "Empty segment", path: "$.a..b", wantErr: ErrInvalidJSONPath},
+		{name: "Trailing dot", path: "$.", wantErr: ErrInvalidJSONPath},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseJSONPath(tC.path)
+
+			assertResponseEquality(t, err, tC.wantErr)
+
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("\ngot '%#v'\nwant '%#v'", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestJSONPathSet(t *testing.T) {
+	testCases := []struct {
+		name    string
+		root    string
+		path    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{name: "New field", root: `{"a":1}`, path: "$.b", value: `2`, want: `{"a":1,"b":2}`},
+		{name: "Missing parents", root: `{}`, path: "$.a.b", value: `"x"`, want: `{"a":{"b":"x"}}`},
+		{name: "Merged object", root: `{"a":{"b":1}}`, path: "$.a", value: `{"c":2}`, want: `{"a":{"b":1,"c":2}}`},
+		{name: "Replaced value", root: `{"a":[1]}`, path: "$.a", value: `{"c":2}`, want: `{"a":{"c":2}}`},
+		{name: "Merged root", root: `{"a":1}`, path: "$", value: `{"b":2}`, want: `{"a":1,"b":2}`},
+		{name: "Parent not an object", root: `{"a":1}`, path: "$.a.b", value: `2`, wantErr: ErrInvalidJSONPath},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			root, err := decodeJSON([]byte(tC.root))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := decodeJSON([]byte(tC.value))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path, err := parseJSONPath(tC.path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := path.set(root, value)
+
+			assertResponseEquality(t, err, tC.wantErr)
+
+			if err != nil {
+				return
+			}
+
+			gotJSON, err := json.Marshal(got)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertResponseEquality(t, string(gotJSON), tC.want)
+		})
+	}
+}
+
+func TestDecodeJSONTrailingData(t *testing.T) {
+	_, err := decodeJSON([]byte(`{"a":1} {"b":2}`))
+
+	assertResponseEquality(t, err, ErrTrailingJSON)
+}
@@ -0,0 +1,367 @@
+package signer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Errors returned by JWE encryption and decryption.
+var (
+	ErrMalformedJWE         = errors.New("signer: malformed JWE")
+	ErrUnsupportedJWE       = errors.New("signer: unsupported JWE algorithm")
+	ErrMissingEncryptionKey = errors.New("signer: payload encryption key is not set")
+	ErrMissingDecryptionKey = errors.New("signer: payload decryption key is not set")
+)
+
+// JWE algorithms of Mastercard Client Encryption.
+const (
+	jweAlgorithm  = "RSA-OAEP-256"
+	jweEncryption = "A256GCM"
+)
+
+// defaultEncryptedValueFieldName is the field that holds an encrypted value if
+// no other name is configured.
+const defaultEncryptedValueFieldName = "encryptedValue"
+
+type jweHeader struct {
+	Algorithm   string `json:"alg"`
+	Encryption  string `json:"enc"`
+	KeyID       string `json:"kid,omitempty"`
+	ContentType string `json:"cty,omitempty"`
+}
+
+// EncryptJWE encrypts the plaintext for the public key as a JWE in compact
+// serialization, using RSA-OAEP-256 key encryption and A256GCM content encryption.
+// The key ID is sent in the kid header parameter if not empty.
+func EncryptJWE(plaintext []byte, publicKey *rsa.PublicKey, keyID string) (string, error) {
+	header, err := json.Marshal(jweHeader{
+		Algorithm:   jweAlgorithm,
+		Encryption:  jweEncryption,
+		KeyID:       keyID,
+		ContentType: "application/json",
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	cek, err := generateRandomBytes(32)
+
+	if err != nil {
+		return "", err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, cek, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(cek)
+
+	if err != nil {
+		return "", err
+	}
+
+	iv, err := generateRandomBytes(gcm.NonceSize())
+
+	if err != nil {
+		return "", err
+	}
+
+	encodedHeader := base64.RawURLEncoding.EncodeToString(header)
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(encodedHeader))
+	tagStart := len(sealed) - gcm.Overhead()
+
+	return strings.Join([]string{
+		encodedHeader,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(sealed[:tagStart]),
+		base64.RawURLEncoding.EncodeToString(sealed[tagStart:]),
+	}, "."), nil
+}
+
+// DecryptJWE decrypts a JWE in compact serialization that was encrypted with
+// RSA-OAEP-256 and A256GCM.
+func DecryptJWE(token string, privateKey *rsa.PrivateKey) ([]byte, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 5 {
+		return nil, ErrMalformedJWE
+	}
+
+	decoded := make([][]byte, len(parts))
+
+	for i, part := range parts {
+		var err error
+
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return nil, ErrMalformedJWE
+		}
+	}
+
+	var header jweHeader
+
+	if err := json.Unmarshal(decoded[0], &header); err != nil {
+		return nil, ErrMalformedJWE
+	}
+
+	if header.Algorithm != jweAlgorithm || header.Encryption != jweEncryption {
+		return nil, ErrUnsupportedJWE
+	}
+
+	cek, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, decoded[1], nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(cek)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded[2]) != gcm.NonceSize() || len(decoded[4]) != gcm.Overhead() {
+		return nil, ErrMalformedJWE
+	}
+
+	return gcm.Open(nil, decoded[2], append(decoded[3], decoded[4]...), []byte(parts[0]))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, ErrMalformedJWE
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// PublicKeyFingerprint returns the hex encoded SHA-256 hash of the DER encoded
+// SubjectPublicKeyInfo of the key, which Mastercard uses to identify encryption keys.
+func PublicKeyFingerprint(publicKey *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(der)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// JWEConfig encrypts and decrypts parts of JSON payloads as JWE, as Mastercard
+// Client Encryption does. Paths are "$" for the whole payload or "$.a.b" for a
+// field.
+type JWEConfig struct {
+	// EncryptionKey is the public key request fields are encrypted for.
+	EncryptionKey *rsa.PublicKey
+
+	// KeyID is sent as kid. If empty, PublicKeyFingerprint of EncryptionKey is used.
+	KeyID string
+
+	// DecryptionKey decrypts response fields.
+	DecryptionKey *rsa.PrivateKey
+
+	// EncryptionPaths map the path of each value to encrypt to the path of the
+	// object its encrypted value is stored in.
+	EncryptionPaths map[string]string
+
+	// DecryptionPaths map the path of each object holding an encrypted value to
+	// the path its decrypted value is stored at.
+	DecryptionPaths map[string]string
+
+	// EncryptedValueFieldName is the field of the encrypted value. If empty,
+	// "encryptedValue" is used.
+	EncryptedValueFieldName string
+}
+
+// EncryptPayload encrypts the values at EncryptionPaths. Paths missing from the
+// payload are skipped, and without EncryptionPaths the payload is returned as is.
+func (c *JWEConfig) EncryptPayload(payload []byte) ([]byte, error) {
+	if len(c.EncryptionPaths) == 0 {
+		return payload, nil
+	}
+
+	if c.EncryptionKey == nil {
+		return nil, ErrMissingEncryptionKey
+	}
+
+	keyID := c.KeyID
+
+	if keyID == "" {
+		var err error
+
+		if keyID, err = PublicKeyFingerprint(c.EncryptionKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return encryptPaths(payload, c.EncryptionPaths, func(plaintext []byte) (map[string]interface{}, error) {
+		token, err := EncryptJWE(plaintext, c.EncryptionKey, keyID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]interface{}{c.fieldName(): token}, nil
+	})
+}
+
+// DecryptPayload decrypts the values at DecryptionPaths. Paths missing from the
+// payload are skipped, and without DecryptionPaths the payload is returned as is.
+func (c *JWEConfig) DecryptPayload(payload []byte) ([]byte, error) {
+	if len(c.DecryptionPaths) == 0 {
+		return payload, nil
+	}
+
+	if c.DecryptionKey == nil {
+		return nil, ErrMissingDecryptionKey
+	}
+
+	return decryptPaths(payload, c.DecryptionPaths, func(obj map[string]interface{}) ([]byte, bool, error) {
+		token, ok := obj[c.fieldName()].(string)
+
+		if !ok {
+			return nil, false, nil
+		}
+
+		delete(obj, c.fieldName())
+
+		plaintext, err := DecryptJWE(token, c.DecryptionKey)
+
+		return plaintext, true, err
+	})
+}
+
+func (c *JWEConfig) fieldName() string {
+	if c.EncryptedValueFieldName != "" {
+		return c.EncryptedValueFieldName
+	}
+
+	return defaultEncryptedValueFieldName
+}
+
+// encryptPaths replaces the value at each source path with the fields returned
+// by encrypt for its JSON encoding, stored at the destination path.
+func encryptPaths(payload []byte, paths map[string]string, encrypt func([]byte) (map[string]interface{}, error)) ([]byte, error) {
+	root, err := decodeJSON(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, from := range getSortedKeys(paths) {
+		in, err := parseJSONPath(from)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := parseJSONPath(paths[from])
+
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := in.get(root)
+
+		if !ok {
+			continue
+		}
+
+		plaintext, err := json.Marshal(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := encrypt(plaintext)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if root, err = out.set(in.remove(root), fields); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+// decryptPaths replaces each object at a source path, for which decrypt finds
+// an encrypted value, with the decoded plaintext stored at the destination path.
+// decrypt removes the fields of the encrypted value from the object, and other
+// fields are kept.
+func decryptPaths(payload []byte, paths map[string]string, decrypt func(map[string]interface{}) ([]byte, bool, error)) ([]byte, error) {
+	root, err := decodeJSON(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, from := range getSortedKeys(paths) {
+		in, err := parseJSONPath(from)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := parseJSONPath(paths[from])
+
+		if err != nil {
+			return nil, err
+		}
+
+		value, _ := in.get(root)
+		obj, ok := value.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		plaintext, ok, err := decrypt(obj)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		decrypted, err := decodeJSON(plaintext)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(obj) == 0 {
+			root = in.remove(root)
+		}
+
+		if root, err = out.set(root, decrypted); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(root)
+}
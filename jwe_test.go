@@ -0,0 +1,445 @@
+package signer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	encryptionKeyOnce sync.Once
+	encryptionKey     *rsa.PrivateKey
+	encryptionKeyErr  error
+)
+
+// testEncryptionKey returns a 2048-bit key generated once per test run.
+func testEncryptionKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	encryptionKeyOnce.Do(func() {
+		encryptionKey, encryptionKeyErr = rsa.GenerateKey(rand.Reader, 2048)
+	})
+
+	if encryptionKeyErr != nil {
+		t.Fatal(encryptionKeyErr)
+	}
+
+	return encryptionKey
+}
+
+// assertJSONEquality compares two JSON documents regardless of formatting and field order.
+func assertJSONEquality(t *testing.T, got, want string) {
+	t.Helper()
+
+	var gotValue, wantValue interface{}
+
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		t.Fatalf("got invalid JSON '%v': %v", got, err)
+	}
+
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("want invalid JSON '%v': %v", want, err)
+	}
+
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("\ngot '%v'\nwant '%v'", got, want)
+	}
+}
+
+func TestJWERoundTrip(t *testing.T) {
+	key := testEncryptionKey(t)
+	plaintext := []byte(`{"account":"5123456789012345"}`)
+
+	token, err := EncryptJWE(plaintext, &key.PublicKey, "key-1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(token, ".")
+
+	assertResponseEquality(t, len(parts), 5)
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertJSONEquality(t, string(header), `{"alg":"RSA-OAEP-256","enc":"A256GCM","kid":"key-1","cty":"application/json"}`)
+
+	got, err := DecryptJWE(token, key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertResponseEquality(t, string(got), string(plaintext))
+}
+
+func TestDecryptJWEInvalid(t *testing.T) {
+	key := testEncryptionKey(t)
+
+	token, err := EncryptJWE([]byte(`"secret"`), &key.PublicKey, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(token, ".")
+
+	tamperedCiphertext := append([]string(nil), parts...)
+	tamperedCiphertext[3] = base64.RawURLEncoding.EncodeToString([]byte("XXXXXXXX"))
+
+	otherAlgorithm := append([]string(nil), parts...)
+	otherAlgorithm[0] = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RSA-OAEP","enc":"A256GCM"}`))
+
+	testCases := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "Too few parts", token: strings.Join(parts[:4], "."), wantErr: ErrMalformedJWE},
+		{name: "Invalid base64", token: "!." + strings.Join(parts[1:], "."), wantErr: ErrMalformedJWE},
+		{name: "Other algorithm", token: strings.Join(otherAlgorithm, "."), wantErr: ErrUnsupportedJWE},
+		{name: "Tampered ciphertext", token: strings.Join(tamperedCiphertext, ".")},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := DecryptJWE(tC.token, key)
+
+			if err == nil {
+				t.Fatal("got nil error")
+			}
+
+			if tC.wantErr != nil {
+				assertResponseEquality(t, err, tC.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWEConfigPayload(t *testing.T) {
+	key := testEncryptionKey(t)
+
+	fingerprint, err := PublicKeyFingerprint(&key.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name            string
+		encryptionPaths map[string]string
+		decryptionPaths map[string]string
+		fieldName       string
+		payload         string
+		encryptedFields []string
+		removedField    string
+	}{
+		{
+			name:            "Field",
+			encryptionPaths: map[string]string{"$.path.to.foo": "$.path.to.encryptedFoo"},
+			decryptionPaths: map[string]string{"$.path.to.encryptedFoo": "$.path.to.foo"},
+			payload:         `{"path":{"to":{"foo":{"sensitive":"this is a secret!","number":12345678901234567890}}},"other":true}`,
+			encryptedFields: []string{"path", "to", "encryptedFoo", "encryptedValue"},
+			removedField:    "this is a secret!",
+		},
+		{
+			name:            "In place",
+			encryptionPaths: map[string]string{"$.card": "$.card"},
+			decryptionPaths: map[string]string{"$.card": "$.card"},
+			fieldName:       "encryptedData",
+			payload:         `{"card":"5123456789012345"}`,
+			encryptedFields: []string{"card", "encryptedData"},
+			removedField:    "5123456789012345",
+		},
+		{
+			name:            "Whole payload",
+			encryptionPaths: map[string]string{"$": "$"},
+			decryptionPaths: map[string]string{"$": "$"},
+			payload:         `{"sensitive":"this is a secret!"}`,
+			encryptedFields: []string{"encryptedValue"},
+			removedField:    "this is a secret!",
+		},
+		{
+			name:            "Missing path",
+			encryptionPaths: map[string]string{"$.missing": "$.encryptedMissing"},
+			decryptionPaths: map[string]string{"$.encryptedMissing": "$.missing"},
+			payload:         `{"visible":1}`,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &JWEConfig{
+				EncryptionKey:           &key.PublicKey,
+				DecryptionKey:           key,
+				EncryptionPaths:         tC.encryptionPaths,
+				DecryptionPaths:         tC.decryptionPaths,
+				EncryptedValueFieldName: tC.fieldName,
+			}
+
+			encrypted, err := c.EncryptPayload([]byte(tC.payload))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tC.removedField != "" && strings.Contains(string(encrypted), tC.removedField) {
+				t.Errorf("\ngot '%s'\nshould not contain '%v'", encrypted, tC.removedField)
+			}
+
+			var v interface{}
+
+			if err := json.Unmarshal(encrypted, &v); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, field := range tC.encryptedFields {
+				v = v.(map[string]interface{})[field]
+			}
+
+			if token, ok := v.(string); ok {
+				header, _ := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+
+				if !strings.Contains(string(header), fingerprint) {
+					t.Errorf("\ngot header '%s'\nshould contain kid '%v'", header, fingerprint)
+				}
+			} else if len(tC.encryptedFields) > 0 {
+				t.Errorf("got no encrypted value in '%s'", encrypted)
+			}
+
+			decrypted, err := c.DecryptPayload(encrypted)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertJSONEquality(t, string(decrypted), tC.payload)
+
+			if strings.Contains(tC.payload, "12345678901234567890") && !strings.Contains(string(decrypted), "12345678901234567890") {
+				t.Errorf("\ngot '%s'\nshould keep the number 12345678901234567890", decrypted)
+			}
+		})
+	}
+}
+
+func TestJWEConfigErrors(t *testing.T) {
+	key := testEncryptionKey(t)
+
+	testCases := []struct {
+		name    string
+		config  JWEConfig
+		decrypt bool
+		payload string
+		wantErr error
+	}{
+		{
+			name:    "Missing encryption key",
+			config:  JWEConfig{EncryptionPaths: map[string]string{"$": "$"}},
+			payload: `{}`,
+			wantErr: ErrMissingEncryptionKey,
+		},
+		{
+			name:    "Missing decryption key",
+			config:  JWEConfig{DecryptionPaths: map[string]string{"$": "$"}},
+			decrypt: true,
+			payload: `{}`,
+			wantErr: ErrMissingDecryptionKey,
+		},
+		{
+			name:    "Invalid path",
+			config:  JWEConfig{EncryptionKey: &key.PublicKey, EncryptionPaths: map[string]string{"a": "$"}},
+			payload: `{}`,
+			wantErr: ErrInvalidJSONPath,
+		},
+		{
+			name:    "Invalid encrypted value",
+			config:  JWEConfig{DecryptionKey: key, DecryptionPaths: map[string]string{"$": "$"}},
+			decrypt: true,
+			payload: `{"encryptedValue":"a.b.c"}`,
+			wantErr: ErrMalformedJWE,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			var err error
+
+			if tC.decrypt {
+				_, err = tC.config.DecryptPayload([]byte(tC.payload))
+			} else {
+				_, err = tC.config.EncryptPayload([]byte(tC.payload))
+			}
+
+			assertResponseEquality(t, err, tC.wantErr)
+		})
+	}
+}
+
+func TestTransportEncryptsBeforeSigning(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := testEncryptionKey(t)
+
+	// The server holds the private key of the client's encryption key, and
+	// encrypts responses for the client's decryption key. Both are the same here.
+	server := &JWEConfig{
+		EncryptionKey:   &key.PublicKey,
+		DecryptionKey:   key,
+		EncryptionPaths: map[string]string{"$.result": "$.encryptedResult"},
+		DecryptionPaths: map[string]string{"$.encryptedCard": "$.card"},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := Verify(r, &privateKey.PublicKey, nil); err != nil {
+			t.Error(err)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if strings.Contains(string(body), "5123456789012345") {
+			t.Errorf("got plaintext request body '%s'", body)
+		}
+
+		decrypted, err := server.DecryptPayload(body)
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		assertJSONEquality(t, string(decrypted), `{"card":"5123456789012345"}`)
+
+		res, err := server.EncryptPayload([]byte(`{"result":"approved"}`))
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(res)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			Signer: NewSigner(consumerKey, privateKey),
+			Encryption: &JWEConfig{
+				EncryptionKey:   &key.PublicKey,
+				DecryptionKey:   key,
+				EncryptionPaths: map[string]string{"$.card": "$.encryptedCard"},
+				DecryptionPaths: map[string]string{"$.encryptedResult": "$.result"},
+			},
+		},
+	}
+
+	res, err := client.Post(ts.URL, "application/json", strings.NewReader(`{"card":"5123456789012345"}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertJSONEquality(t, string(body), `{"result":"approved"}`)
+	assertResponseEquality(t, res.ContentLength, int64(len(body)))
+}
+
+func TestTransportSkipsEncryptionOfOtherContentTypes(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := testEncryptionKey(t)
+
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "Form", contentType: "application/x-www-form-urlencoded", body: "a=1&card=5123456789012345"},
+		{name: "Binary", contentType: "application/octet-stream", body: "\x00\x01card"},
+		{name: "No content type", body: "card"},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := Verify(r, &privateKey.PublicKey, nil); err != nil {
+					t.Error(err)
+				}
+
+				body, _ := ioutil.ReadAll(r.Body)
+
+				assertResponseEquality(t, string(body), tC.body)
+			}))
+			defer ts.Close()
+
+			client := &http.Client{
+				Transport: &Transport{
+					Signer: NewSigner(consumerKey, privateKey),
+					Encryption: &JWEConfig{
+						EncryptionKey:   &key.PublicKey,
+						EncryptionPaths: map[string]string{"$.card": "$.encryptedCard"},
+					},
+				},
+			}
+
+			req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(tC.body))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tC.contentType != "" {
+				req.Header.Set("Content-Type", tC.contentType)
+			}
+
+			res, err := client.Do(req)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res.Body.Close()
+		})
+	}
+}
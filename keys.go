@@ -0,0 +1,168 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned for keys that can't be decoded.
+var (
+	ErrEmptyKey         = errors.New("signer: signing key is empty")
+	ErrNoPEMData        = errors.New("signer: signing key is not PEM encoded")
+	ErrTrailingData     = errors.New("signer: signing key has unexpected data after the PEM blocks")
+	ErrMultipleKeys     = errors.New("signer: PEM data holds more than one private key")
+	ErrPasswordRequired = errors.New("signer: key is encrypted, password required")
+)
+
+// KeyFormatError is returned when a key is stored in a format that can't be used for signing.
+type KeyFormatError struct {
+	// Format names the format that was found, e.g. the PEM block type.
+	Format string
+}
+
+func (e *KeyFormatError) Error() string {
+	return "signer: unsupported key format: " + e.Format
+}
+
+// ParsePrivateKey parses a PEM encoded RSA private key in PKCS#1 ("RSA PRIVATE KEY")
+// or PKCS#8 ("PRIVATE KEY") form. Other PEM blocks, such as the certificates of
+// a key and certificate bundle, are skipped.
+func ParsePrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	return parsePEMPrivateKey(pemData, "", false)
+}
+
+// ParseEncryptedPrivateKey parses a password protected PEM encoded RSA private key.
+// Both legacy encrypted PKCS#1 blocks and PKCS#8 "ENCRYPTED PRIVATE KEY" blocks
+// are supported. Unencrypted keys are accepted as well.
+func ParseEncryptedPrivateKey(pemData []byte, password string) (*rsa.PrivateKey, error) {
+	return parsePEMPrivateKey(pemData, password, true)
+}
+
+func parsePEMPrivateKey(pemData []byte, password string, hasPassword bool) (*rsa.PrivateKey, error) {
+	if len(bytes.TrimSpace(pemData)) == 0 {
+		return nil, ErrEmptyKey
+	}
+
+	block, err := findPrivateKeyBlock(pemData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	der := block.Bytes
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		if x509.IsEncryptedPEMBlock(block) {
+			if !hasPassword {
+				return nil, ErrPasswordRequired
+			}
+
+			var err error
+
+			der, err = x509.DecryptPEMBlock(block, []byte(password))
+
+			if err == x509.IncorrectPasswordError {
+				return nil, ErrIncorrectPassword
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		privateKey, err := x509.ParsePKCS1PrivateKey(der)
+
+		if err != nil {
+			return nil, fmt.Errorf("signer: invalid PKCS#1 private key: %v", err)
+		}
+
+		return privateKey, nil
+	case "PRIVATE KEY":
+		return parsePKCS8PrivateKey(der)
+	case "ENCRYPTED PRIVATE KEY":
+		if !hasPassword {
+			return nil, ErrPasswordRequired
+		}
+
+		var err error
+
+		der, err = decryptPKCS8(der, password)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return parsePKCS8PrivateKey(der)
+	default:
+		return nil, &KeyFormatError{Format: block.Type}
+	}
+}
+
+// findPrivateKeyBlock returns the only private key block of the PEM data, or the
+// first block if none is a private key.
+func findPrivateKeyBlock(pemData []byte) (*pem.Block, error) {
+	var first, key *pem.Block
+
+	rest := pemData
+
+	for {
+		block, r := pem.Decode(rest)
+
+		if block == nil {
+			break
+		}
+
+		rest = r
+
+		if first == nil {
+			first = block
+		}
+
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+
+		if key != nil {
+			return nil, ErrMultipleKeys
+		}
+
+		key = block
+	}
+
+	if first == nil {
+		return nil, ErrNoPEMData
+	}
+
+	if len(bytes.TrimSpace(rest)) != 0 {
+		return nil, ErrTrailingData
+	}
+
+	if key == nil {
+		return first, nil
+	}
+
+	return key, nil
+}
+
+// parsePKCS8PrivateKey parses a DER encoded PKCS#8 key and makes sure it is an RSA key.
+func parsePKCS8PrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+
+	if err != nil {
+		return nil, fmt.Errorf("signer: invalid PKCS#8 private key: %v", err)
+	}
+
+	privateKey, ok := key.(*rsa.PrivateKey)
+
+	if !ok {
+		return nil, &KeyFormatError{Format: fmt.Sprintf("PKCS#8 %T", key)}
+	}
+
+	return privateKey, nil
+}
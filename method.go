@@ -0,0 +1,143 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+)
+
+// SignatureMethod signs signature base strings for one oauth_signature_method.
+// Implementations must be safe for concurrent use.
+type SignatureMethod interface {
+	// Name returns the oauth_signature_method value, e.g. "HMAC-SHA1".
+	Name() string
+
+	// Sign returns the oauth_signature of the signature base string. The token
+	// secret is empty when no token is used.
+	Sign(signatureBaseString, tokenSecret string) (string, error)
+}
+
+// RSASHA256 is the RSA-SHA256 signature method required by Mastercard APIs. Key
+// must have an RSA public key.
+type RSASHA256 struct {
+	Key crypto.Signer
+}
+
+// Name returns "RSA-SHA256".
+func (RSASHA256) Name() string {
+	return "RSA-SHA256"
+}
+
+// Sign signs the signature base string with RSASSA-PKCS1-v1_5 over SHA-256.
+func (m RSASHA256) Sign(signatureBaseString, tokenSecret string) (string, error) {
+	return signWithKey(signatureBaseString, m.Key, crypto.SHA256)
+}
+
+// RSASHA1 is the RSA-SHA1 signature method of RFC 5849 section 3.4.3. Key must
+// have an RSA public key.
+type RSASHA1 struct {
+	Key crypto.Signer
+}
+
+// Name returns "RSA-SHA1".
+func (RSASHA1) Name() string {
+	return "RSA-SHA1"
+}
+
+// Sign signs the signature base string with RSASSA-PKCS1-v1_5 over SHA-1.
+func (m RSASHA1) Sign(signatureBaseString, tokenSecret string) (string, error) {
+	return signWithKey(signatureBaseString, m.Key, crypto.SHA1)
+}
+
+// HMACSHA1 is the HMAC-SHA1 signature method of RFC 5849 section 3.4.2.
+type HMACSHA1 struct {
+	ConsumerSecret string
+}
+
+// Name returns "HMAC-SHA1".
+func (HMACSHA1) Name() string {
+	return "HMAC-SHA1"
+}
+
+// Sign signs the signature base string with HMAC-SHA1 keyed by the consumer and token secrets.
+func (m HMACSHA1) Sign(signatureBaseString, tokenSecret string) (string, error) {
+	return signWithHMAC(signatureBaseString, m.ConsumerSecret, tokenSecret, sha1.New), nil
+}
+
+// HMACSHA256 is the HMAC-SHA256 signature method, HMAC-SHA1 with SHA-256 as hash function.
+type HMACSHA256 struct {
+	ConsumerSecret string
+}
+
+// Name returns "HMAC-SHA256".
+func (HMACSHA256) Name() string {
+	return "HMAC-SHA256"
+}
+
+// Sign signs the signature base string with HMAC-SHA256 keyed by the consumer and token secrets.
+func (m HMACSHA256) Sign(signatureBaseString, tokenSecret string) (string, error) {
+	return signWithHMAC(signatureBaseString, m.ConsumerSecret, tokenSecret, sha256.New), nil
+}
+
+// Plaintext is the PLAINTEXT signature method of RFC 5849 section 3.4.4. It sends
+// the secrets as the signature and must only be used over TLS.
+type Plaintext struct {
+	ConsumerSecret string
+}
+
+// Name returns "PLAINTEXT".
+func (Plaintext) Name() string {
+	return "PLAINTEXT"
+}
+
+// Sign returns the encoded consumer and token secrets. The signature base string isn't used.
+func (m Plaintext) Sign(signatureBaseString, tokenSecret string) (string, error) {
+	return hmacKey(m.ConsumerSecret, tokenSecret), nil
+}
+
+// signWithKey signs the signature base string with RSASSA-PKCS1-v1_5 over the
+// given hash. Keys other than RSA keys are rejected with a KeyFormatError.
+func signWithKey(signatureBaseString string, key crypto.Signer, hash crypto.Hash) (string, error) {
+	if key == nil {
+		return "", ErrEmptyKey
+	}
+
+	if publicKey := key.Public(); !isRSAPublicKey(publicKey) {
+		return "", &KeyFormatError{Format: fmt.Sprintf("%T", publicKey)}
+	}
+
+	h := hash.New()
+	h.Write([]byte(signatureBaseString))
+
+	signature, err := key.Sign(rand.Reader, h.Sum(nil), hash)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func isRSAPublicKey(publicKey crypto.PublicKey) bool {
+	_, ok := publicKey.(*rsa.PublicKey)
+
+	return ok
+}
+
+func signWithHMAC(signatureBaseString, consumerSecret, tokenSecret string, h func() hash.Hash) string {
+	mac := hmac.New(h, []byte(hmacKey(consumerSecret, tokenSecret)))
+	mac.Write([]byte(signatureBaseString))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// hmacKey joins the encoded consumer and token secrets as described in RFC 5849 section 3.4.2.
+func hmacKey(consumerSecret, tokenSecret string) string {
+	return percentEncode(consumerSecret) + "&" + percentEncode(tokenSecret)
+}
@@ -0,0 +1,151 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSignatureMethods(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signature base string of OAuth Core 1.0 appendix A.5.1.
+	sbs := "GET&http%3A%2F%2Fphotos.example.net%2Fphotos&file%3Dvacation.jpg%26oauth_consumer_key%3Ddpf43f3p2l4k3l03%26oauth_nonce%3Dkllo9940pd9333jh%26oauth_signature_method%3DHMAC-SHA1%26oauth_timestamp%3D1191242096%26oauth_token%3Dnnch734d00sl2jdk%26oauth_version%3D1.0%26size%3Doriginal"
+
+	testCases := []struct {
+		name        string
+		method      SignatureMethod
+		tokenSecret string
+		wantName    string
+		want        string
+	}{
+		{
+			name:        "HMAC-SHA1",
+			method:      HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+			tokenSecret: "pfkkdhi9sl3r4s00",
+			wantName:    "HMAC-SHA1",
+			want:        "tR3+Ty81lMeYAr/Fid0kMTYa/WM=",
+		},
+		{
+			name:        "HMAC-SHA1 without token",
+			method:      HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"},
+			tokenSecret: "",
+			wantName:    "HMAC-SHA1",
+			want:        "53jgttsWLqA74Y7pXpdaQdhgDfI=",
+		},
+		{
+			name:        "HMAC-SHA256",
+			method:      HMACSHA256{ConsumerSecret: "kd94hf93k423kf44"},
+			tokenSecret: "pfkkdhi9sl3r4s00",
+			wantName:    "HMAC-SHA256",
+			want:        "0gCtTYQAxqCKhIE0sltgx7UgHkAs10vrpuYE7xpRBnE=",
+		},
+		{
+			name:     "RSA-SHA1",
+			method:   RSASHA1{Key: privateKey},
+			wantName: "RSA-SHA1",
+			want:     "oW80kVhzje3jCt1bl8lHgs69Kepjdo+oXHavBH3puqr3VpCC3gKvwA08Nt1bgVIYpgvhn/AT8PoPk43vNIzr8hi/VnsulELNG01Pw7N0W03wQ0TNc/wQEoxasG+2d0FFyqKSGhuinxcnl48xC5srmksj0eoyoXz2wPFoty1R4TM=",
+		},
+		{
+			name:        "PLAINTEXT",
+			method:      Plaintext{ConsumerSecret: "djr9rjt0jd78jf88"},
+			tokenSecret: "jjd99$tj88uiths3",
+			wantName:    "PLAINTEXT",
+			want:        "djr9rjt0jd78jf88&jjd99%24tj88uiths3",
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tC.method.Sign(sbs, tC.tokenSecret)
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			assertResponseEquality(t, tC.method.Name(), tC.wantName)
+			assertResponseEquality(t, got, tC.want)
+		})
+	}
+}
+
+func TestSignerWithSignatureMethod(t *testing.T) {
+	s := NewSigner("dpf43f3p2l4k3l03", nil,
+		WithSignatureMethod(HMACSHA1{ConsumerSecret: "kd94hf93k423kf44"}),
+		WithNonceSource(FixedNonce("kllo9940pd9333jh")),
+	)
+
+	got, err := s.AuthorizationHeader("http://photos.example.net/photos?file=vacation.jpg&size=original", http.MethodGet, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `oauth_signature_method="HMAC-SHA1"`
+
+	if !strings.Contains(got, want) {
+		t.Errorf("\ngot '%v'\nshould contain '%v'", got, want)
+	}
+}
+
+func TestRSASignatureMethodsRejectOtherKeys(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		method  SignatureMethod
+		wantErr error
+	}{
+		{
+			name:    "RSA-SHA256 with ECDSA key",
+			method:  RSASHA256{Key: ecdsaKey},
+			wantErr: &KeyFormatError{Format: "*ecdsa.PublicKey"},
+		},
+		{
+			name:    "RSA-SHA1 with ECDSA key",
+			method:  RSASHA1{Key: ecdsaKey},
+			wantErr: &KeyFormatError{Format: "*ecdsa.PublicKey"},
+		},
+		{
+			name:    "RSA-SHA256 without key",
+			method:  RSASHA256{},
+			wantErr: ErrEmptyKey,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tC.method.Sign("GET&http%3A%2F%2Fexample.com%2F&", "")
+
+			if !reflect.DeepEqual(err, tC.wantErr) {
+				t.Errorf("\ngot error '%v'\nwant '%v'", err, tC.wantErr)
+			}
+
+			assertResponseEquality(t, got, "")
+		})
+	}
+
+	if _, err := NewSigner(consumerKey, ecdsaKey).AuthorizationHeader("https://example.com/", http.MethodGet, ""); err == nil {
+		t.Error("got nil error signing with an ECDSA key")
+	}
+}
@@ -0,0 +1,154 @@
+package signer
+
+import (
+	"context"
+	"crypto/rsa"
+	"net/http"
+)
+
+// defaultMaxBodyBytes is the body size limit of an Authenticator whose options don't set one.
+const defaultMaxBodyBytes = 1 << 20
+
+// ErrUnknownConsumer is returned when the KeyLookup of an Authenticator doesn't
+// know the consumer key of a request.
+const ErrUnknownConsumer = VerifyError("signer: unknown OAuth consumer key")
+
+// KeyLookup finds the public key of a consumer. It returns a nil key and a nil
+// error for unknown consumers. Implementations must be safe for concurrent use.
+type KeyLookup interface {
+	PublicKey(ctx context.Context, consumerKey string) (*rsa.PublicKey, error)
+}
+
+// KeyLookupFunc is a function that implements KeyLookup.
+type KeyLookupFunc func(ctx context.Context, consumerKey string) (*rsa.PublicKey, error)
+
+// PublicKey calls f(ctx, consumerKey).
+func (f KeyLookupFunc) PublicKey(ctx context.Context, consumerKey string) (*rsa.PublicKey, error) {
+	return f(ctx, consumerKey)
+}
+
+// Authenticator is net/http middleware that passes on only requests with a
+// valid OAuth signature, as checked by Verify. The body stays readable for the
+// next handler, and the consumer key is available from ConsumerKeyFromContext.
+// Rejected requests get 401 Unauthorized with an OAuth WWW-Authenticate
+// challenge, bodies over Options.MaxBodyBytes get 413 Request Entity Too Large,
+// and failures of the key lookup or the nonce store get 500 Internal Server Error.
+type Authenticator struct {
+	// Keys finds the public key of the consumer of a request.
+	Keys KeyLookup
+
+	// Options are passed to Verify. If MaxBodyBytes is zero or Options is nil,
+	// bodies are limited to 1 MiB; a negative MaxBodyBytes removes the limit.
+	Options *VerifyOptions
+
+	// Replay, if set, rejects verified requests that were seen before.
+	Replay *ReplayGuard
+
+	// Realm is sent in the WWW-Authenticate challenge, if not empty.
+	Realm string
+}
+
+type consumerKeyContextKey struct{}
+
+// ConsumerKeyFromContext returns the consumer key of a request passed on by an Authenticator.
+func ConsumerKeyFromContext(ctx context.Context) (string, bool) {
+	consumerKey, ok := ctx.Value(consumerKeyContextKey{}).(string)
+
+	return consumerKey, ok
+}
+
+// Handler returns a handler that authenticates requests before passing them to next.
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		consumerKey, status, err := a.authenticate(req)
+
+		if err != nil {
+			a.reject(w, status, err)
+			return
+		}
+
+		ctx := context.WithValue(req.Context(), consumerKeyContextKey{}, consumerKey)
+
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// authenticate verifies the request and returns its consumer key, or the status
+// code to reject it with. Failures of the key lookup and the nonce store are
+// server errors; anything wrong with the request itself is the client's.
+func (a *Authenticator) authenticate(req *http.Request) (string, int, error) {
+	header, err := ParseAuthorizationHeader(req.Header.Get("Authorization"))
+
+	if err != nil {
+		return "", http.StatusUnauthorized, err
+	}
+
+	consumerKey, ok := header.Params["oauth_consumer_key"]
+
+	if !ok {
+		return "", http.StatusUnauthorized, ErrMissingParameter
+	}
+
+	publicKey, err := a.Keys.PublicKey(req.Context(), consumerKey)
+
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+
+	if publicKey == nil {
+		return "", http.StatusUnauthorized, ErrUnknownConsumer
+	}
+
+	if err := Verify(req, publicKey, a.verifyOptions()); err == ErrBodyTooLarge {
+		return "", http.StatusRequestEntityTooLarge, err
+	} else if err != nil {
+		return "", http.StatusUnauthorized, err
+	}
+
+	if a.Replay != nil {
+		if err := a.Replay.Check(header.Params); err != nil {
+			if _, ok := err.(VerifyError); ok {
+				return "", http.StatusUnauthorized, err
+			}
+
+			return "", http.StatusInternalServerError, err
+		}
+	}
+
+	return consumerKey, http.StatusOK, nil
+}
+
+// verifyOptions returns Options with the default body size limit applied.
+func (a *Authenticator) verifyOptions() *VerifyOptions {
+	var opts VerifyOptions
+
+	if a.Options != nil {
+		opts = *a.Options
+	}
+
+	if opts.MaxBodyBytes == 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+
+	return &opts
+}
+
+// reject answers a request that failed authentication. Server errors aren't
+// disclosed to the client, and only 401 responses carry the OAuth challenge.
+func (a *Authenticator) reject(w http.ResponseWriter, status int, err error) {
+	switch status {
+	case http.StatusUnauthorized:
+		challenge := "OAuth"
+
+		if a.Realm != "" {
+			challenge += ` realm="` + quoteEscaper.Replace(a.Realm) + `"`
+		}
+
+		w.Header().Set("WWW-Authenticate", challenge)
+		http.Error(w, err.Error(), status)
+	case http.StatusInternalServerError:
+		http.Error(w, http.StatusText(status), status)
+	default:
+		http.Error(w, err.Error(), status)
+	}
+}
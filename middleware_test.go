@@ -0,0 +1,335 @@
+package signer
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestAuthenticator(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSigner(consumerKey, privateKey)
+
+	signed := func(method, body string) *http.Request {
+		req := httptest.NewRequest(method, "https://example.com/service?a=1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		if err := s.SignRequest(req); err != nil {
+			t.Fatal(err)
+		}
+
+		return req
+	}
+
+	lookupErr := errors.New("database is down")
+
+	keys := KeyLookupFunc(func(ctx context.Context, key string) (*rsa.PublicKey, error) {
+		switch key {
+		case consumerKey:
+			return &privateKey.PublicKey, nil
+		case "broken":
+			return nil, lookupErr
+		}
+
+		return nil, nil
+	})
+
+	testCases := []struct {
+		name       string
+		req        func() *http.Request
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Valid",
+			req:        func() *http.Request { return signed(http.MethodPost, `{"amount":100}`) },
+			wantStatus: http.StatusOK,
+			wantBody:   `aaa!aaa {"amount":100}`,
+		},
+		{
+			name:       "Valid without body",
+			req:        func() *http.Request { return signed(http.MethodGet, "") },
+			wantStatus: http.StatusOK,
+			wantBody:   "aaa!aaa",
+		},
+		{
+			name: "Missing header",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "https://example.com/service", nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   ErrMissingAuthorization.Error(),
+		},
+		{
+			name: "Missing consumer key",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "https://example.com/service", nil)
+				req.Header.Set("Authorization", `OAuth oauth_nonce="abc"`)
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   ErrMissingParameter.Error(),
+		},
+		{
+			name: "Unknown consumer",
+			req: func() *http.Request {
+				req := signed(http.MethodGet, "")
+				req.Header.Set("Authorization", strings.Replace(req.Header.Get("Authorization"), "aaa%21aaa", "bbb", 1))
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   ErrUnknownConsumer.Error(),
+		},
+		{
+			name: "Tampered body",
+			req: func() *http.Request {
+				req := signed(http.MethodPost, `{"amount":100}`)
+				req.Body = ioutil.NopCloser(strings.NewReader(`{"amount":900}`))
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   ErrBodyHashMismatch.Error(),
+		},
+		{
+			name: "Malformed query",
+			req: func() *http.Request {
+				req := signed(http.MethodGet, "")
+				req.URL.RawQuery = "x=%zz"
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   ErrMalformedRequest.Error(),
+		},
+		{
+			name: "Malformed form body",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "https://example.com/service?a=1", strings.NewReader("a=1"))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+				if err := s.SignRequest(req); err != nil {
+					t.Fatal(err)
+				}
+
+				req.Body = ioutil.NopCloser(strings.NewReader("a=%zz"))
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   ErrMalformedRequest.Error(),
+		},
+		{
+			name: "Failing body",
+			req: func() *http.Request {
+				req := signed(http.MethodPost, `{"amount":100}`)
+				req.Body = ioutil.NopCloser(iotest.TimeoutReader(strings.NewReader(`{"amount":100}`)))
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   iotest.ErrTimeout.Error(),
+		},
+		{
+			name: "Body too large",
+			req: func() *http.Request {
+				return signed(http.MethodPost, `{"amount":100,"note":"`+strings.Repeat("a", 64)+`"}`)
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   ErrBodyTooLarge.Error(),
+		},
+		{
+			name: "Failing lookup",
+			req: func() *http.Request {
+				req := signed(http.MethodGet, "")
+				req.Header.Set("Authorization", strings.Replace(req.Header.Get("Authorization"), "aaa%21aaa", "broken", 1))
+				return req
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := &Authenticator{Keys: keys, Options: &VerifyOptions{MaxBodyBytes: 64}, Realm: "Example"}
+
+			handler := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				consumerKey, _ := ConsumerKeyFromContext(r.Context())
+				body, _ := ioutil.ReadAll(r.Body)
+
+				w.Write([]byte(consumerKey + " " + string(body)))
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tC.req())
+
+			assertResponseEquality(t, rec.Code, tC.wantStatus)
+			assertResponseEquality(t, strings.TrimSpace(rec.Body.String()), tC.wantBody)
+
+			wantChallenge := ""
+
+			if tC.wantStatus == http.StatusUnauthorized {
+				wantChallenge = `OAuth realm="Example"`
+			}
+
+			assertResponseEquality(t, rec.Header().Get("WWW-Authenticate"), wantChallenge)
+		})
+	}
+}
+
+func TestAuthenticatorMaxBodyBytes(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSigner(consumerKey, privateKey)
+	large := `{"note":"` + strings.Repeat("a", defaultMaxBodyBytes) + `"}`
+
+	testCases := []struct {
+		name       string
+		options    *VerifyOptions
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "Default limit",
+			options:    nil,
+			body:       large,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "Zero limit",
+			options:    &VerifyOptions{Scheme: "https"},
+			body:       large,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "Within default limit",
+			options:    nil,
+			body:       `{"amount":100}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "No limit",
+			options:    &VerifyOptions{MaxBodyBytes: -1},
+			body:       large,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := &Authenticator{
+				Keys: KeyLookupFunc(func(ctx context.Context, key string) (*rsa.PublicKey, error) {
+					return &privateKey.PublicKey, nil
+				}),
+				Options: tC.options,
+			}
+
+			handler := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodPost, "https://example.com/service", strings.NewReader(tC.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			if err := s.SignRequest(req); err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assertResponseEquality(t, rec.Code, tC.wantStatus)
+		})
+	}
+}
+
+func TestAuthenticatorRejectsReplay(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Authenticator{
+		Keys: KeyLookupFunc(func(ctx context.Context, key string) (*rsa.PublicKey, error) {
+			return &privateKey.PublicKey, nil
+		}),
+		Replay: &ReplayGuard{Store: NewMemoryNonceStore(100)},
+	}
+
+	handler := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/service", nil)
+
+	if err := NewSigner(consumerKey, privateKey).SignRequest(req); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assertResponseEquality(t, rec.Code, want)
+	}
+}
+
+// fullNonceStore is a NonceStore that has no room left.
+type fullNonceStore struct{}
+
+func (fullNonceStore) Store(record NonceRecord, expires, now time.Time) (bool, error) {
+	return false, ErrNonceStoreFull
+}
+
+func TestAuthenticatorNonceStoreFull(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Authenticator{
+		Keys: KeyLookupFunc(func(ctx context.Context, key string) (*rsa.PublicKey, error) {
+			return &privateKey.PublicKey, nil
+		}),
+		Replay: &ReplayGuard{Store: fullNonceStore{}},
+	}
+
+	handler := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/service", nil)
+
+	if err := NewSigner(consumerKey, privateKey).SignRequest(req); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assertResponseEquality(t, rec.Code, http.StatusInternalServerError)
+	assertResponseEquality(t, rec.Header().Get("WWW-Authenticate"), "")
+}
+
+func TestConsumerKeyFromContext(t *testing.T) {
+	_, ok := ConsumerKeyFromContext(context.Background())
+
+	assertResponseEquality(t, ok, false)
+}
@@ -0,0 +1,300 @@
+package signer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"unicode/utf16"
+)
+
+// ErrIncorrectPassword is returned when an encrypted key can't be decrypted with the given password.
+var ErrIncorrectPassword = errors.New("signer: incorrect password")
+
+var (
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBES2                         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2                        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1                  = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256                = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidDESEDE3CBC                    = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAES128CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts a DER encoded PKCS#8 EncryptedPrivateKeyInfo.
+func decryptPKCS8(der []byte, password string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+
+	return decryptPBE(info.Algorithm, info.EncryptedData, password)
+}
+
+// decryptPBE decrypts data with a password based encryption scheme from
+// PKCS#5 (PBES2) or PKCS#12 (pbeWithSHAAnd3-KeyTripleDES-CBC).
+func decryptPBE(algorithm pkix.AlgorithmIdentifier, data []byte, password string) ([]byte, error) {
+	var block cipher.Block
+	var iv []byte
+
+	switch {
+	case algorithm.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC):
+		var params pbeParams
+
+		if _, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &params); err != nil {
+			return nil, err
+		}
+
+		bmpPassword := bmpString(password)
+		key := pkcs12KDF(sha1.New, bmpPassword, params.Salt, params.Iterations, 1, 24)
+		iv = pkcs12KDF(sha1.New, bmpPassword, params.Salt, params.Iterations, 2, 8)
+
+		var err error
+
+		block, err = des.NewTripleDESCipher(key)
+
+		if err != nil {
+			return nil, err
+		}
+	case algorithm.Algorithm.Equal(oidPBES2):
+		var err error
+
+		block, iv, err = pbes2Cipher(algorithm.Parameters.FullBytes, password)
+
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, &KeyFormatError{Format: "encryption algorithm " + algorithm.Algorithm.String()}
+	}
+
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 || len(iv) != block.BlockSize() {
+		return nil, ErrIncorrectPassword
+	}
+
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, data)
+
+	return unpad(decrypted, block.BlockSize())
+}
+
+func pbes2Cipher(parameters []byte, password string) (cipher.Block, []byte, error) {
+	var params pbes2Params
+
+	if _, err := asn1.Unmarshal(parameters, &params); err != nil {
+		return nil, nil, err
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, nil, &KeyFormatError{Format: "key derivation function " + params.KeyDerivationFunc.Algorithm.String()}
+	}
+
+	var kdfParams pbkdf2Params
+
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, nil, err
+	}
+
+	var prf func() hash.Hash
+
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		return nil, nil, &KeyFormatError{Format: "PBKDF2 PRF " + kdfParams.PRF.Algorithm.String()}
+	}
+
+	var keyLength int
+	var newCipher func([]byte) (cipher.Block, error)
+
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength, newCipher = 16, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLength, newCipher = 24, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength, newCipher = 32, aes.NewCipher
+	case params.EncryptionScheme.Algorithm.Equal(oidDESEDE3CBC):
+		keyLength, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, nil, &KeyFormatError{Format: "encryption scheme " + params.EncryptionScheme.Algorithm.String()}
+	}
+
+	var iv []byte
+
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, nil, err
+	}
+
+	key := pbkdf2([]byte(password), kdfParams.Salt, kdfParams.Iterations, keyLength, prf)
+
+	block, err := newCipher(key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return block, iv, nil
+}
+
+// pbkdf2 derives a key as described in RFC 8018 section 5.2.
+func pbkdf2(password, salt []byte, iterations, keyLength int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hashLength := prf.Size()
+	blocks := (keyLength + hashLength - 1) / hashLength
+
+	derived := make([]byte, 0, blocks*hashLength)
+	u := make([]byte, hashLength)
+
+	for i := 1; i <= blocks; i++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)})
+		u = prf.Sum(u[:0])
+
+		t := make([]byte, hashLength)
+		copy(t, u)
+
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		derived = append(derived, t...)
+	}
+
+	return derived[:keyLength]
+}
+
+// pkcs12KDF derives key material as described in RFC 7292 appendix B.2.
+func pkcs12KDF(h func() hash.Hash, password, salt []byte, iterations int, id byte, size int) []byte {
+	const v = 64
+
+	fill := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+
+		out := make([]byte, v*((len(b)+v-1)/v))
+
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+
+		return out
+	}
+
+	d := make([]byte, v)
+
+	for i := range d {
+		d[i] = id
+	}
+
+	I := append(fill(salt), fill(password)...)
+
+	var derived []byte
+
+	for len(derived) < size {
+		hasher := h()
+		hasher.Write(d)
+		hasher.Write(I)
+		a := hasher.Sum(nil)
+
+		for n := 1; n < iterations; n++ {
+			hasher.Reset()
+			hasher.Write(a)
+			a = hasher.Sum(a[:0])
+		}
+
+		derived = append(derived, a...)
+
+		b := make([]byte, v)
+
+		for i := range b {
+			b[i] = a[i%len(a)]
+		}
+
+		for j := 0; j < len(I); j += v {
+			carry := 1
+
+			for k := v - 1; k >= 0; k-- {
+				sum := int(I[j+k]) + int(b[k]) + carry
+				I[j+k] = byte(sum)
+				carry = sum >> 8
+			}
+		}
+	}
+
+	return derived[:size]
+}
+
+// bmpString encodes the password as a NULL terminated big-endian UTF-16 string.
+func bmpString(password string) []byte {
+	encoded := utf16.Encode([]rune(password))
+	out := make([]byte, 0, 2*len(encoded)+2)
+
+	for _, r := range encoded {
+		out = append(out, byte(r>>8), byte(r))
+	}
+
+	return append(out, 0, 0)
+}
+
+// unpad removes PKCS#7 padding. Invalid padding almost always means a wrong password.
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, ErrIncorrectPassword
+	}
+
+	n := int(data[len(data)-1])
+
+	if n == 0 || n > blockSize {
+		return nil, ErrIncorrectPassword
+	}
+
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, ErrIncorrectPassword
+		}
+	}
+
+	return data[:len(data)-n], nil
+}
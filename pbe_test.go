@@ -0,0 +1,115 @@
+package signer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// Test vectors from RFC 6070.
+	testCases := []struct {
+		name       string
+		password   string
+		salt       string
+		iterations int
+		keyLength  int
+		want       string
+	}{
+		{
+			name:       "1 iteration",
+			password:   "password",
+			salt:       "salt",
+			iterations: 1,
+			keyLength:  20,
+			want:       "0c60c80f961f0e71f3a9b524af6012062fe037a6",
+		},
+		{
+			name:       "4096 iterations",
+			password:   "password",
+			salt:       "salt",
+			iterations: 4096,
+			keyLength:  20,
+			want:       "4b007901b765489abead49d926f721d065a429c1",
+		},
+		{
+			name:       "Multiple blocks",
+			password:   "passwordPASSWORDpassword",
+			salt:       "saltSALTsaltSALTsaltSALTsaltSALTsalt",
+			iterations: 4096,
+			keyLength:  25,
+			want:       "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038",
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := pbkdf2([]byte(tC.password), []byte(tC.salt), tC.iterations, tC.keyLength, sha1.New)
+
+			assertResponseEquality(t, hex.EncodeToString(got), tC.want)
+		})
+	}
+}
+
+func TestPKCS12KDF(t *testing.T) {
+	// Test vectors from the Bouncy Castle PKCS#12 test suite.
+	testCases := []struct {
+		name       string
+		password   string
+		salt       string
+		iterations int
+		id         byte
+		size       int
+		want       string
+	}{
+		{
+			name:       "Key",
+			password:   "smeg",
+			salt:       "0a58cf64530d823f",
+			iterations: 1,
+			id:         1,
+			size:       24,
+			want:       "8aaae6297b6cb04642ab5b077851284eb7128f1a2a7fbca3",
+		},
+		{
+			name:       "IV",
+			password:   "smeg",
+			salt:       "0a58cf64530d823f",
+			iterations: 1,
+			id:         2,
+			size:       8,
+			want:       "79993dfe048d3b76",
+		},
+		{
+			name:       "Iterations",
+			password:   "queeg",
+			salt:       "1682c0fc5b3f7ec5",
+			iterations: 1000,
+			id:         1,
+			size:       24,
+			want:       "483dd6e919d7de2e8e648ba8f862f3fbfbdc2bcb2c02957f",
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			salt, err := hex.DecodeString(tC.salt)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := pkcs12KDF(sha1.New, bmpString(tC.password), salt, tC.iterations, tC.id, tC.size)
+
+			assertResponseEquality(t, hex.EncodeToString(got), tC.want)
+		})
+	}
+}
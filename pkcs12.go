@@ -0,0 +1,213 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"io/ioutil"
+)
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+	oidKeyBag                   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidSHA1                     = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256                   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+// LoadPKCS12File reads a .p12 file and returns the RSA private key it contains.
+func LoadPKCS12File(path, password string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePKCS12(data, password)
+}
+
+// ParsePKCS12 returns the RSA private key of a password protected PKCS#12 archive.
+// Keys encrypted with PBES2 (AES) or pbeWithSHAAnd3-KeyTripleDES-CBC are supported.
+func ParsePKCS12(data []byte, password string) (*rsa.PrivateKey, error) {
+	var pfx pfxPdu
+
+	if _, err := asn1.Unmarshal(data, &pfx); err != nil {
+		return nil, &KeyFormatError{Format: "not a PKCS#12 archive"}
+	}
+
+	if !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return nil, &KeyFormatError{Format: "PKCS#12 with public key integrity mode"}
+	}
+
+	var authSafe []byte
+
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authSafe); err != nil {
+		return nil, err
+	}
+
+	if len(pfx.MacData.Mac.Algorithm.Algorithm) > 0 {
+		if err := verifyPKCS12MAC(&pfx.MacData, authSafe, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var contents []contentInfo
+
+	if _, err := asn1.Unmarshal(authSafe, &contents); err != nil {
+		return nil, err
+	}
+
+	// The first error of skipped encrypted contents is returned if no key is
+	// found, as the key may have been in them.
+	var skipped error
+
+	for _, ci := range contents {
+		var safeContents []byte
+
+		switch {
+		case ci.ContentType.Equal(oidDataContentType):
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &safeContents); err != nil {
+				return nil, err
+			}
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedData
+
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+				return nil, err
+			}
+
+			var err error
+
+			safeContents, err = decryptPBE(ed.EncryptedContentInfo.ContentEncryptionAlgorithm, ed.EncryptedContentInfo.EncryptedContent, password)
+
+			// Encrypted contents usually hold only certificates, often with
+			// algorithms such as RC2 that aren't needed to get to the key.
+			if _, ok := err.(*KeyFormatError); ok {
+				if skipped == nil {
+					skipped = err
+				}
+
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+
+		key, err := findPrivateKey(safeContents, password)
+
+		if err != nil || key != nil {
+			return key, err
+		}
+	}
+
+	if skipped != nil {
+		return nil, skipped
+	}
+
+	return nil, errors.New("signer: no private key found in PKCS#12 archive")
+}
+
+// findPrivateKey returns the first private key of the SafeContents, or nil if there is none.
+func findPrivateKey(safeContents []byte, password string) (*rsa.PrivateKey, error) {
+	var bags []safeBag
+
+	if _, err := asn1.Unmarshal(safeContents, &bags); err != nil {
+		return nil, err
+	}
+
+	for _, bag := range bags {
+		switch {
+		case bag.ID.Equal(oidKeyBag):
+			return parsePKCS8PrivateKey(bag.Value.Bytes)
+		case bag.ID.Equal(oidPKCS8ShroudedKeyBag):
+			der, err := decryptPKCS8(bag.Value.Bytes, password)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return parsePKCS8PrivateKey(der)
+		}
+	}
+
+	return nil, nil
+}
+
+// verifyPKCS12MAC checks the integrity of the archive, which also tells whether the password is right.
+func verifyPKCS12MAC(md *macData, message []byte, password string) error {
+	var h func() hash.Hash
+
+	switch {
+	case md.Mac.Algorithm.Algorithm.Equal(oidSHA1):
+		h = sha1.New
+	case md.Mac.Algorithm.Algorithm.Equal(oidSHA256):
+		h = sha256.New
+	default:
+		return &KeyFormatError{Format: "PKCS#12 MAC algorithm " + md.Mac.Algorithm.Algorithm.String()}
+	}
+
+	key := pkcs12KDF(h, bmpString(password), md.MacSalt, md.Iterations, 3, h().Size())
+
+	mac := hmac.New(h, key)
+	mac.Write(message)
+
+	if !hmac.Equal(mac.Sum(nil), md.Mac.Digest) {
+		return ErrIncorrectPassword
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package signer
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPKCS12File(t *testing.T) {
+	want, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		file     string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "PBES2 AES-256",
+			file:     "testdata/rsa.p12",
+			password: "secret",
+		},
+		{
+			name:     "PBE SHA1 3DES",
+			file:     "testdata/rsa-3des.p12",
+			password: "secret",
+		},
+		{
+			name:     "Wrong password",
+			file:     "testdata/rsa.p12",
+			password: "wrong",
+			wantErr:  ErrIncorrectPassword,
+		},
+		{
+			name:     "Not PKCS#12",
+			file:     "testdata/rsa-pkcs1.pem",
+			password: "secret",
+			wantErr:  &KeyFormatError{Format: "not a PKCS#12 archive"},
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := LoadPKCS12File(tC.file, tC.password)
+
+			if !reflect.DeepEqual(err, tC.wantErr) {
+				t.Fatalf("\ngot error '%v'\nwant '%v'", err, tC.wantErr)
+			}
+
+			if tC.wantErr == nil && got.N.Cmp(want.N) != 0 {
+				t.Errorf("got a different key than '%v'", tC.file)
+			}
+		})
+	}
+}
+
+func TestParsePKCS12UnsupportedEncryptedContents(t *testing.T) {
+	// pbeWithSHAAnd40BitRC2-CBC, which is used for certificates but isn't supported.
+	oidRC2 := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+
+	marshal := func(v interface{}) []byte {
+		der, err := asn1.Marshal(v)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return der
+	}
+
+	// explicit wraps DER in the [0] EXPLICIT tag of ContentInfo.content.
+	explicit := func(der []byte) asn1.RawValue {
+		return asn1.RawValue{FullBytes: marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, IsCompound: true, Bytes: der})}
+	}
+
+	authSafe := marshal([]contentInfo{{
+		ContentType: oidEncryptedDataContentType,
+		Content: explicit(marshal(encryptedData{
+			EncryptedContentInfo: encryptedContentInfo{
+				ContentType:                oidDataContentType,
+				ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRC2},
+				EncryptedContent:           []byte("encrypted key bag"),
+			},
+		})),
+	}})
+
+	data := marshal(pfxPdu{
+		Version: 3,
+		AuthSafe: contentInfo{
+			ContentType: oidDataContentType,
+			Content:     explicit(marshal(authSafe)),
+		},
+	})
+
+	_, err := ParsePKCS12(data, "secret")
+
+	want := &KeyFormatError{Format: "encryption algorithm " + oidRC2.String()}
+
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("\ngot error '%v'\nwant '%v'", err, want)
+	}
+}
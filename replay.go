@@ -0,0 +1,244 @@
+package signer
+
+import (
+	"container/heap"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Reasons for a rejected replay check.
+const (
+	ErrInvalidTimestamp = VerifyError("signer: invalid oauth_timestamp")
+	ErrStaleTimestamp   = VerifyError("signer: oauth_timestamp is outside the allowed window")
+	ErrReplayedNonce    = VerifyError("signer: oauth_nonce has already been used")
+)
+
+// ErrNonceStoreFull is returned by MemoryNonceStore when it holds as many
+// unexpired nonces as its capacity allows. Requests are rejected rather than
+// forgetting nonces that could still be replayed.
+var ErrNonceStoreFull = errors.New("signer: nonce store is full")
+
+// defaultReplayWindow is the ReplayGuard window if none is set.
+const defaultReplayWindow = 5 * time.Minute
+
+// NonceRecord identifies a request for replay protection.
+type NonceRecord struct {
+	ConsumerKey string
+	Nonce       string
+	Timestamp   int64
+}
+
+// NonceStore remembers the nonces of accepted requests. Implementations must be
+// safe for concurrent use.
+type NonceStore interface {
+	// Store records the nonce until expires and reports whether it was new. It
+	// must check and record atomically. Records that expired before now may be
+	// forgotten.
+	Store(record NonceRecord, expires, now time.Time) (bool, error)
+}
+
+// ReplayGuard rejects requests whose oauth_timestamp is outside a window around
+// the current time, and requests whose consumer key, nonce and timestamp were
+// seen before. A nonce is remembered until its timestamp leaves the window, after
+// which the timestamp check alone rejects it.
+//
+// Check requests only after their signature is verified, so that forged requests
+// can't use up the nonces of legitimate clients.
+type ReplayGuard struct {
+	// Store remembers the nonces of accepted requests.
+	Store NonceStore
+
+	// Window is the largest accepted difference between oauth_timestamp and the
+	// current time, in either direction. If zero, 5 minutes is used.
+	Window time.Duration
+
+	// Clock tells the current time. If nil, the system clock is used.
+	Clock Clock
+}
+
+// Check accepts the protocol parameters of a request that wasn't seen before.
+func (g *ReplayGuard) Check(oauthParams map[string]string) error {
+	consumerKey, ok := oauthParams["oauth_consumer_key"]
+	nonce, hasNonce := oauthParams["oauth_nonce"]
+	timestamp, hasTimestamp := oauthParams["oauth_timestamp"]
+
+	if !ok || !hasNonce || !hasTimestamp {
+		return ErrMissingParameter
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil || seconds < 0 {
+		return ErrInvalidTimestamp
+	}
+
+	now := g.now()
+	window := g.window()
+	signedAt := time.Unix(seconds, 0)
+
+	if signedAt.Before(now.Add(-window)) || signedAt.After(now.Add(window)) {
+		return ErrStaleTimestamp
+	}
+
+	stored, err := g.Store.Store(NonceRecord{
+		ConsumerKey: consumerKey,
+		Nonce:       nonce,
+		Timestamp:   seconds,
+	}, signedAt.Add(window), now)
+
+	if err != nil {
+		return err
+	}
+
+	if !stored {
+		return ErrReplayedNonce
+	}
+
+	return nil
+}
+
+// CheckRequest checks the Authorization header of a received request.
+func (g *ReplayGuard) CheckRequest(req *http.Request) error {
+	header, err := ParseAuthorizationHeader(req.Header.Get("Authorization"))
+
+	if err != nil {
+		return err
+	}
+
+	return g.Check(header.Params)
+}
+
+func (g *ReplayGuard) now() time.Time {
+	if g.Clock != nil {
+		return g.Clock.Now()
+	}
+
+	return time.Now()
+}
+
+func (g *ReplayGuard) window() time.Duration {
+	if g.Window > 0 {
+		return g.Window
+	}
+
+	return defaultReplayWindow
+}
+
+// nonceShards is the number of independently locked parts of a MemoryNonceStore.
+const nonceShards = 32
+
+// MemoryNonceStore is a NonceStore that keeps at most a fixed number of nonces
+// in memory. Nonces are spread over independently locked shards, so concurrent
+// requests rarely wait for each other. Create it with NewMemoryNonceStore.
+type MemoryNonceStore struct {
+	shards [nonceShards]nonceShard
+}
+
+// NewMemoryNonceStore returns a MemoryNonceStore for about capacity unexpired
+// nonces. Each shard holds an equal part of the capacity.
+func NewMemoryNonceStore(capacity int) *MemoryNonceStore {
+	perShard := (capacity + nonceShards - 1) / nonceShards
+
+	if perShard < 1 {
+		perShard = 1
+	}
+
+	s := &MemoryNonceStore{}
+
+	for i := range s.shards {
+		s.shards[i].capacity = perShard
+		s.shards[i].records = map[NonceRecord]struct{}{}
+	}
+
+	return s
+}
+
+// Store records the nonce until expires and reports whether it was new. Expired
+// nonces are forgotten first, and ErrNonceStoreFull is returned if the shard of
+// the nonce is still full.
+func (s *MemoryNonceStore) Store(record NonceRecord, expires, now time.Time) (bool, error) {
+	return s.shard(record).store(record, expires, now)
+}
+
+// shard returns the shard a nonce belongs to.
+func (s *MemoryNonceStore) shard(record NonceRecord) *nonceShard {
+	h := fnv.New32a()
+	h.Write([]byte(record.ConsumerKey))
+	h.Write([]byte{0})
+	h.Write([]byte(record.Nonce))
+
+	return &s.shards[h.Sum32()%nonceShards]
+}
+
+// Len returns the number of remembered nonces, including expired ones that
+// haven't been forgotten yet.
+func (s *MemoryNonceStore) Len() int {
+	n := 0
+
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.mu.Lock()
+		n += len(shard.records)
+		shard.mu.Unlock()
+	}
+
+	return n
+}
+
+type nonceShard struct {
+	mu       sync.Mutex
+	capacity int
+	records  map[NonceRecord]struct{}
+	queue    expiryQueue
+}
+
+func (s *nonceShard) store(record NonceRecord, expires, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.queue) > 0 && s.queue[0].expires.Before(now) {
+		delete(s.records, heap.Pop(&s.queue).(nonceEntry).record)
+	}
+
+	if _, ok := s.records[record]; ok {
+		return false, nil
+	}
+
+	if len(s.records) >= s.capacity {
+		return false, ErrNonceStoreFull
+	}
+
+	s.records[record] = struct{}{}
+	heap.Push(&s.queue, nonceEntry{record: record, expires: expires})
+
+	return true, nil
+}
+
+type nonceEntry struct {
+	record  NonceRecord
+	expires time.Time
+}
+
+// expiryQueue is a heap of nonces ordered by expiry.
+type expiryQueue []nonceEntry
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].expires.Before(q[j].expires) }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x interface{}) {
+	*q = append(*q, x.(nonceEntry))
+}
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return entry
+}
@@ -0,0 +1,213 @@
+package signer
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplayGuardCheck(t *testing.T) {
+	now := time.Unix(1571227200, 0)
+
+	params := func(consumerKey, nonce string, timestamp time.Time) map[string]string {
+		return map[string]string{
+			"oauth_consumer_key": consumerKey,
+			"oauth_nonce":        nonce,
+			"oauth_timestamp":    strconv.FormatInt(timestamp.Unix(), 10),
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		previous []map[string]string
+		params   map[string]string
+		want     error
+	}{
+		{
+			name:   "Fresh",
+			params: params("key", "nonce", now),
+			want:   nil,
+		},
+		{
+			name:     "Replayed",
+			previous: []map[string]string{params("key", "nonce", now)},
+			params:   params("key", "nonce", now),
+			want:     ErrReplayedNonce,
+		},
+		{
+			name:     "Same nonce of another consumer",
+			previous: []map[string]string{params("other", "nonce", now)},
+			params:   params("key", "nonce", now),
+			want:     nil,
+		},
+		{
+			name:     "Same nonce with another timestamp",
+			previous: []map[string]string{params("key", "nonce", now.Add(-time.Second))},
+			params:   params("key", "nonce", now),
+			want:     nil,
+		},
+		{
+			name:   "Edge of window",
+			params: params("key", "nonce", now.Add(-5*time.Minute)),
+			want:   nil,
+		},
+		{
+			name:   "Too old",
+			params: params("key", "nonce", now.Add(-5*time.Minute-time.Second)),
+			want:   ErrStaleTimestamp,
+		},
+		{
+			name:   "Too far in the future",
+			params: params("key", "nonce", now.Add(5*time.Minute+time.Second)),
+			want:   ErrStaleTimestamp,
+		},
+		{
+			name:   "Invalid timestamp",
+			params: map[string]string{"oauth_consumer_key": "key", "oauth_nonce": "nonce", "oauth_timestamp": "soon"},
+			want:   ErrInvalidTimestamp,
+		},
+		{
+			name:   "Missing nonce",
+			params: map[string]string{"oauth_consumer_key": "key", "oauth_timestamp": "1571227200"},
+			want:   ErrMissingParameter,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := &ReplayGuard{
+				Store: NewMemoryNonceStore(100),
+				Clock: FixedClock(now),
+			}
+
+			for _, p := range tC.previous {
+				if err := g.Check(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			assertResponseEquality(t, g.Check(tC.params), tC.want)
+		})
+	}
+}
+
+func TestReplayGuardCheckRequest(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/request", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewSigner(consumerKey, privateKey).SignRequest(req); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &ReplayGuard{Store: NewMemoryNonceStore(100)}
+
+	assertResponseEquality(t, g.CheckRequest(req), nil)
+	assertResponseEquality(t, g.CheckRequest(req), ErrReplayedNonce)
+
+	req.Header.Del("Authorization")
+
+	assertResponseEquality(t, g.CheckRequest(req), ErrMissingAuthorization)
+}
+
+func TestMemoryNonceStoreForgetsExpiredNonces(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1571227200, 0)}
+
+	g := &ReplayGuard{
+		Store:  NewMemoryNonceStore(1),
+		Window: time.Minute,
+		Clock:  clock,
+	}
+
+	check := func(nonce string) error {
+		return g.Check(map[string]string{
+			"oauth_consumer_key": "key",
+			"oauth_nonce":        nonce,
+			"oauth_timestamp":    strconv.FormatInt(clock.Now().Unix(), 10),
+		})
+	}
+
+	assertResponseEquality(t, check("a"), nil)
+
+	// Every shard holds a single nonce, so another nonce of the same shard is
+	// rejected until the first one expires.
+	store := g.Store.(*MemoryNonceStore)
+	first := store.shard(NonceRecord{ConsumerKey: "key", Nonce: "a"})
+	nonce := ""
+
+	for i := 0; store.shard(NonceRecord{ConsumerKey: "key", Nonce: nonce}) != first; i++ {
+		nonce = strconv.Itoa(i)
+	}
+
+	assertResponseEquality(t, check(nonce), ErrNonceStoreFull)
+
+	clock.Advance(time.Minute + time.Second)
+
+	assertResponseEquality(t, check(nonce), nil)
+	assertResponseEquality(t, store.Len(), 1)
+}
+
+func TestMemoryNonceStoreConcurrentUse(t *testing.T) {
+	store := NewMemoryNonceStore(1000)
+	expires := time.Now().Add(time.Minute)
+
+	var wg sync.WaitGroup
+	var accepted int64
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for n := 0; n < 20; n++ {
+				record := NonceRecord{ConsumerKey: "key", Nonce: strconv.Itoa(n), Timestamp: 1}
+
+				ok, err := store.Store(record, expires, time.Now())
+
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if ok {
+					atomic.AddInt64(&accepted, 1)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	assertResponseEquality(t, accepted, int64(20))
+	assertResponseEquality(t, store.Len(), 20)
+}
+
+func BenchmarkMemoryNonceStoreParallel(b *testing.B) {
+	store := NewMemoryNonceStore(1 << 20)
+	expires := time.Now().Add(time.Hour)
+
+	var counter int64
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := atomic.AddInt64(&counter, 1)
+			store.Store(NonceRecord{ConsumerKey: "key", Nonce: strconv.FormatInt(n, 10)}, expires, time.Time{})
+		}
+	})
+}
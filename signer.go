@@ -2,13 +2,7 @@ package signer
 
 import (
 	"crypto"
-	"crypto/rsa"
-	"crypto/sha256"
-	"crypto/x509"
-	"encoding/base64"
-	"encoding/pem"
-	"io"
-	"math/rand"
+	"crypto/rand"
 	"net/url"
 	"sort"
 	"strconv"
@@ -20,47 +14,335 @@ const nonceLength = 8
 
 // GetAuthorizationHeader creates a Mastercard API compliant OAuth Authorization header
 func GetAuthorizationHeader(uri, method, payload, consumerKey string, signingKey []byte) (string, error) {
-	var err error
-	var queryParams map[string][]string
-	var oauthParams map[string]string
-	var baseURI string
-	var signature string
-
-	queryParams, err = extractQueryParams(uri)
+	privateKey, err := ParsePrivateKey(signingKey)
 
 	if err != nil {
 		return "", err
 	}
 
-	oauthParams, err = getOAuthParams(consumerKey, payload)
+	return NewSigner(consumerKey, privateKey).AuthorizationHeader(uri, method, payload)
+}
+
+// Signer creates Mastercard API compliant OAuth Authorization headers with a key
+// that is parsed only once. It is safe for concurrent use by multiple goroutines.
+type Signer struct {
+	consumerKey string
+	method      SignatureMethod
+	nonceSource NonceSource
+	clock       Clock
+	token       string
+	tokenSecret string
+	realm       string
+
+	transmission Transmission
+
+	bodyHashAlgorithm crypto.Hash
+	bodyHashPolicy    BodyHashPolicy
+
+	spool    bool
+	spoolDir string
+}
+
+// Option configures a Signer.
+type Option func(*Signer)
+
+// WithNonceSource sets the source of oauth_nonce values. By default nonces of
+// 8 random alphanumeric characters are used.
+func WithNonceSource(source NonceSource) Option {
+	return func(s *Signer) {
+		s.nonceSource = source
+	}
+}
+
+// WithSignatureMethod sets the signature method, replacing the default RSA-SHA256
+// with the key given to NewSigner. The key may then be nil.
+func WithSignatureMethod(method SignatureMethod) Option {
+	return func(s *Signer) {
+		s.method = method
+	}
+}
+
+// WithClock sets the clock oauth_timestamp values are taken from. By default the system clock is used.
+func WithClock(clock Clock) Option {
+	return func(s *Signer) {
+		s.clock = clock
+	}
+}
+
+// WithToken sets the token credentials of three-legged OAuth. The token is sent
+// as oauth_token and the secret is used by the signature method.
+func WithToken(token, tokenSecret string) Option {
+	return func(s *Signer) {
+		s.token = token
+		s.tokenSecret = tokenSecret
+	}
+}
+
+// WithRealm sets the realm parameter of the Authorization header. The realm is
+// not signed.
+func WithRealm(realm string) Option {
+	return func(s *Signer) {
+		s.realm = realm
+	}
+}
+
+// NewSigner returns a Signer for the given consumer key and parsed signing key.
+// The key must be an RSA key, such as an *rsa.PrivateKey or a hardware backed
+// crypto.Signer with an RSA public key; signing fails with a KeyFormatError otherwise.
+func NewSigner(consumerKey string, key crypto.Signer, opts ...Option) *Signer {
+	s := &Signer{
+		consumerKey: consumerKey,
+		method:      RSASHA256{Key: key},
+		nonceSource: RandomNonceSource{Length: nonceLength},
+		clock:       systemClock{},
+
+		bodyHashAlgorithm: crypto.SHA256,
+		bodyHashPolicy:    AlwaysBodyHash,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// NonceSource generates the oauth_nonce of every signed request. Implementations
+// must be safe for concurrent use.
+type NonceSource interface {
+	Nonce() (string, error)
+}
+
+// RandomNonceSource generates alphanumeric nonces of Length characters using
+// crypto/rand. If Length isn't positive, nonces are 8 characters long.
+type RandomNonceSource struct {
+	Length int
+}
+
+// Nonce returns a new random nonce.
+func (r RandomNonceSource) Nonce() (string, error) {
+	if r.Length <= 0 {
+		return getNonce(nonceLength)
+	}
+
+	return getNonce(r.Length)
+}
+
+// FixedNonce is a NonceSource that always returns the same nonce. It is meant for
+// tests and for reproducing a signature from logged values, never for real requests.
+type FixedNonce string
+
+// Nonce returns the fixed nonce.
+func (n FixedNonce) Nonce() (string, error) {
+	return string(n), nil
+}
+
+// Clock tells the current time. Implementations must be safe for concurrent use.
+type Clock interface {
+	Now() time.Time
+}
+
+// FixedClock is a Clock that always returns the same time. It is meant for tests
+// and for reproducing a signature from logged values.
+type FixedClock time.Time
+
+// Now returns the fixed time.
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// AuthorizationHeader creates a Mastercard API compliant OAuth Authorization header
+func (s *Signer) AuthorizationHeader(uri, method, payload string) (string, error) {
+	result, err := s.Sign(uri, method, payload)
 
 	if err != nil {
 		return "", err
 	}
 
-	paramString := toOAuthParamString(queryParams, oauthParams)
+	return result.Header, nil
+}
+
+// SigningResult holds the Authorization header of a signed request together with
+// the intermediate values it was computed from. Compare them with the values the
+// server reports when it rejects a signature.
+type SigningResult struct {
+	// BaseURI is the normalized request URI of RFC 5849 section 3.4.1.2.
+	BaseURI string
+
+	// NormalizedParams is the normalized parameter string of RFC 5849 section 3.4.1.3.2.
+	NormalizedParams string
+
+	// BaseString is the signature base string that was signed.
+	BaseString string
+
+	// BodyHash is the oauth_body_hash value, empty if it was left out.
+	BodyHash string
+
+	Nonce     string
+	Timestamp string
+
+	// Header is the value of the Authorization header.
+	Header string
+
+	token     string
+	signature string
+	params    map[string]string
+}
+
+// String describes the result for logging. The oauth_token and oauth_signature
+// values are redacted, since the PLAINTEXT signature is made of the secrets.
+func (r SigningResult) String() string {
+	var redacted []string
+
+	if r.token != "" {
+		token := percentEncode(r.token)
+
+		// The base string encodes the parameter string a second time.
+		redacted = append(redacted,
+			"oauth_token%3D"+percentEncode(token), "oauth_token%3DREDACTED",
+			"oauth_token="+token, "oauth_token=REDACTED",
+			`oauth_token="`+token+`"`, `oauth_token="REDACTED"`,
+		)
+	}
+
+	if r.signature != "" {
+		redacted = append(redacted,
+			`oauth_signature="`+percentEncode(r.signature)+`"`, `oauth_signature="REDACTED"`,
+		)
+	}
+
+	replacer := strings.NewReplacer(redacted...)
+
+	var b strings.Builder
+
+	for _, field := range []struct {
+		name, value string
+	}{
+		{"base uri", r.BaseURI},
+		{"normalized params", r.NormalizedParams},
+		{"base string", r.BaseString},
+		{"body hash", r.BodyHash},
+		{"nonce", r.Nonce},
+		{"timestamp", r.Timestamp},
+		{"header", r.Header},
+	} {
+		b.WriteString(field.name + ": " + replacer.Replace(field.value) + "\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
 
-	baseURI, err = getBaseURIString(uri)
+// Sign creates the Authorization header like AuthorizationHeader, and returns it
+// with the intermediate values of the signature.
+func (s *Signer) Sign(uri, method, payload string) (*SigningResult, error) {
+	bodyHash, err := s.getBodyHash(method, "", []byte(payload))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sign(uri, method, "", bodyHash, nil)
+}
+
+// authorizationHeader creates the Authorization header for a form-urlencoded
+// body or a body with the given hash, and additional protocol parameters such as
+// oauth_callback or oauth_verifier. An empty body hash leaves out oauth_body_hash.
+func (s *Signer) authorizationHeader(uri, method, formBody, bodyHash string, extraParams map[string]string) (string, error) {
+	result, err := s.sign(uri, method, formBody, bodyHash, extraParams)
 
 	if err != nil {
 		return "", err
 	}
 
-	sbs := getSignatureBaseString(method, baseURI, paramString)
+	return result.Header, nil
+}
+
+// sign is authorizationHeader returning the intermediate values as well.
+func (s *Signer) sign(uri, method, formBody, bodyHash string, extraParams map[string]string) (*SigningResult, error) {
+	if s.method == nil {
+		return nil, ErrMissingSignatureMethod
+	}
+
+	oauthParams, err := s.getOAuthParams(bodyHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range extraParams {
+		oauthParams[k] = v
+	}
+
+	result := &SigningResult{
+		BodyHash:  bodyHash,
+		Nonce:     oauthParams["oauth_nonce"],
+		Timestamp: oauthParams["oauth_timestamp"],
+		token:     oauthParams["oauth_token"],
+	}
 
-	signature, err = signSignatureBaseString(sbs, signingKey)
+	result.BaseURI, result.NormalizedParams, err = normalizeRequest(uri, formBody, oauthParams)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.BaseString = getSignatureBaseString(method, result.BaseURI, result.NormalizedParams)
+
+	result.signature, err = s.method.Sign(result.BaseString, s.tokenSecret)
+
+	if err != nil {
+		return nil, err
+	}
+
+	oauthParams["oauth_signature"] = result.signature
+
+	result.params = oauthParams
+	result.Header = AuthorizationHeader{Realm: s.realm, Params: oauthParams}.String()
+
+	return result, nil
+}
+
+// buildSignatureBaseString runs the whole signature base string pipeline for
+// the request URI, method, form-urlencoded body and OAuth protocol parameters.
+// The form body is empty for requests of any other content type.
+func buildSignatureBaseString(uri, method, formBody string, oauthParams map[string]string) (string, error) {
+	baseURI, paramString, err := normalizeRequest(uri, formBody, oauthParams)
 
 	if err != nil {
 		return "", err
 	}
 
-	encodedSignature := url.QueryEscape(signature)
+	return getSignatureBaseString(method, baseURI, paramString), nil
+}
+
+// normalizeRequest returns the base string URI and the normalized parameter
+// string of a request.
+func normalizeRequest(uri, formBody string, oauthParams map[string]string) (string, string, error) {
+	queryParams, err := extractQueryParams(uri)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := addEncodedParams(queryParams, formBody); err != nil {
+		return "", "", err
+	}
+
+	paramString := toOAuthParamString(queryParams, oauthParams)
 
-	oauthParams["oauth_signature"] = encodedSignature
+	baseURI, err := getBaseURIString(uri)
 
-	authorizationString := getAuthorizationString(oauthParams)
+	if err != nil {
+		return "", "", err
+	}
 
-	return authorizationString, nil
+	return baseURI, paramString, nil
 }
 
 func extractQueryParams(uri string) (map[string][]string, error) {
@@ -72,79 +354,127 @@ func extractQueryParams(uri string) (map[string][]string, error) {
 		return queryMap, err
 	}
 
-	for _, param := range strings.Split(parsedURL.RawQuery, "&") {
+	err = addEncodedParams(queryMap, parsedURL.RawQuery)
+
+	return queryMap, err
+}
+
+// addEncodedParams adds the parameters of a form-urlencoded string, such as a
+// query or a form body, to the map. Repeated parameters are all kept, as they
+// must each appear in the normalized parameter string.
+func addEncodedParams(params map[string][]string, encoded string) error {
+	for _, param := range splitQuery(encoded) {
 		keyValuePair := strings.SplitN(param, "=", 2)
 
-		key := keyValuePair[0]
-		value := keyValuePair[1]
+		key, err := normalizeQueryComponent(keyValuePair[0])
+
+		if err != nil {
+			return err
+		}
+
+		value := ""
+
+		if len(keyValuePair) == 2 {
+			value, err = normalizeQueryComponent(keyValuePair[1])
 
-		if _, ok := queryMap[key]; ok {
-			if !contains(queryMap[key], value) {
-				queryMap[key] = append(queryMap[key], value)
-				sort.Strings(queryMap[key])
+			if err != nil {
+				return err
 			}
-		} else {
-			queryMap[key] = []string{value}
 		}
+
+		params[key] = append(params[key], value)
+	}
+
+	return nil
+}
+
+// splitQuery splits a raw query into its non-empty parameters. Both "&" and ";"
+// separate parameters, as recommended for application/x-www-form-urlencoded data.
+func splitQuery(rawQuery string) []string {
+	return strings.FieldsFunc(rawQuery, func(r rune) bool {
+		return r == '&' || r == ';'
+	})
+}
+
+// normalizeQueryComponent decodes a form-urlencoded query name or value and
+// re-encodes it as required by RFC 5849 section 3.6.
+func normalizeQueryComponent(component string) (string, error) {
+	decoded, err := url.QueryUnescape(component)
+
+	if err != nil {
+		return "", err
 	}
 
-	return queryMap, nil
+	return percentEncode(decoded), nil
 }
 
-func getOAuthParams(consumerKey, payload string) (map[string]string, error) {
+func (s *Signer) getOAuthParams(bodyHash string) (map[string]string, error) {
 	var err error
 	OAuthParams := map[string]string{}
 
-	OAuthParams["oauth_body_hash"] = getBodyHash(payload)
-	OAuthParams["oauth_consumer_key"] = consumerKey
+	if bodyHash != "" {
+		OAuthParams["oauth_body_hash"] = bodyHash
+	}
+
+	OAuthParams["oauth_consumer_key"] = s.consumerKey
 
-	OAuthParams["oauth_nonce"], err = getNonce()
+	OAuthParams["oauth_nonce"], err = s.nonceSource.Nonce()
 
 	if err != nil {
 		return map[string]string{}, err
 	}
 
-	OAuthParams["oauth_signature_method"] = "RSA-SHA256"
-	OAuthParams["oauth_timestamp"] = getTimestamp()
+	OAuthParams["oauth_signature_method"] = s.method.Name()
+	OAuthParams["oauth_timestamp"] = getTimestamp(s.clock)
+
+	if s.token != "" {
+		OAuthParams["oauth_token"] = s.token
+	}
+
 	OAuthParams["oauth_version"] = "1.0"
 
 	return OAuthParams, nil
 }
 
-func getTimestamp() string {
-	nowUnix := time.Now().Unix()
+func getTimestamp(clock Clock) string {
+	nowUnix := clock.Now().Unix()
 
 	timestamp := strconv.Itoa(int(nowUnix))
 
 	return timestamp
 }
 
-// getNonce returns securely generated random string.
-func getNonce() (string, error) {
+// getNonce returns securely generated random alphanumeric string of given length.
+func getNonce(n int) (string, error) {
 	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	buf, err := generateRandomBytes(nonceLength)
+	// Bytes from maxByte up are rejected, so every letter is equally likely.
+	const maxByte = 256 - 256%len(letters)
 
-	if err != nil {
-		return "", err
-	}
+	nonce := make([]byte, 0, n)
 
-	for i, b := range buf {
-		buf[i] = letters[b%byte(len(letters))]
-	}
+	for len(nonce) < n {
+		buf, err := generateRandomBytes(n - len(nonce))
 
-	return string(buf), nil
-}
+		if err != nil {
+			return "", err
+		}
 
-func getBodyHash(payload string) string {
-	hash := sha256.Sum256([]byte(payload))
+		for _, b := range buf {
+			if int(b) < maxByte {
+				nonce = append(nonce, letters[int(b)%len(letters)])
+			}
+		}
+	}
 
-	return base64.StdEncoding.EncodeToString(hash[:])
+	return string(nonce), nil
 }
 
 func toOAuthParamString(queryParams map[string][]string, oauthParams map[string]string) string {
 	var paramsBuilder strings.Builder
 
 	for k, v := range oauthParams {
+		k, v = percentEncode(k), percentEncode(v)
+
 		if _, ok := queryParams[k]; ok {
 			queryParams[k] = append(queryParams[k], v)
 		} else {
@@ -165,6 +495,9 @@ func toOAuthParamString(queryParams map[string][]string, oauthParams map[string]
 	return strings.TrimSuffix(paramsBuilder.String(), "&")
 }
 
+// getBaseURIString returns the base string URI of RFC 5849 section 3.4.1.2:
+// lowercase scheme and host, no default port, no userinfo, query or fragment, and
+// the path as it is sent, with "/" for an empty path.
 func getBaseURIString(uri string) (string, error) {
 	URL, err := url.Parse(uri)
 
@@ -172,55 +505,77 @@ func getBaseURIString(uri string) (string, error) {
 		return "", err
 	}
 
-	base := URL.Scheme + "://" + strings.ToLower(URL.Host) + URL.Path
+	scheme := strings.ToLower(URL.Scheme)
+	host := strings.ToLower(URL.Hostname())
+
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	if port := URL.Port(); port != "" && !isDefaultPort(scheme, port) {
+		host += ":" + port
+	}
+
+	path := URL.EscapedPath()
+
+	if path == "" {
+		path = "/"
+	}
+
+	base := scheme + "://" + host + path
 	return base, nil
 }
 
+func isDefaultPort(scheme, port string) bool {
+	return scheme == "http" && port == "80" || scheme == "https" && port == "443"
+}
+
 func getSignatureBaseString(method, baseURI, params string) string {
-	sbs := url.QueryEscape(method) + "&" + url.QueryEscape(baseURI) + "&" + url.QueryEscape(params)
+	sbs := percentEncode(strings.ToUpper(method)) + "&" + percentEncode(baseURI) + "&" + percentEncode(params)
 
 	return sbs
 }
 
 func signSignatureBaseString(signatureBaseString string, signingKey []byte) (string, error) {
-	var Reader io.Reader
-	var err error
-	var privateKey *rsa.PrivateKey
-	var signature []byte
-
-	block, _ := pem.Decode(signingKey)
-
-	privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
-
-	if err != nil {
-		return "", err
-	}
-
-	hashed := sha256.Sum256([]byte(signatureBaseString))
-
-	signature, err = rsa.SignPKCS1v15(Reader, privateKey, crypto.SHA256, hashed[:])
+	privateKey, err := ParsePrivateKey(signingKey)
 
 	if err != nil {
 		return "", err
 	}
 
-	return base64.StdEncoding.EncodeToString(signature), nil
+	return signWithKey(signatureBaseString, privateKey, crypto.SHA256)
 }
 
-func getAuthorizationString(oauthParams map[string]string) string {
-	var authorizationBuilder strings.Builder
+// percentEncode encodes a string as described in RFC 5849 section 3.6. Only the
+// unreserved characters of RFC 3986 are left as is, everything else is encoded
+// as UTF-8 octets with uppercase hexadecimal digits.
+func percentEncode(s string) string {
+	const hex = "0123456789ABCDEF"
 
-	authorizationBuilder.WriteString("OAuth ")
+	var encodedBuilder strings.Builder
 
-	keys := getSortedKeys(oauthParams)
+	encodedBuilder.Grow(len(s))
 
-	for _, k := range keys {
-		authorizationBuilder.WriteString(k + `="` + oauthParams[k] + `",`)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if isUnreserved(c) {
+			encodedBuilder.WriteByte(c)
+		} else {
+			encodedBuilder.WriteByte('%')
+			encodedBuilder.WriteByte(hex[c>>4])
+			encodedBuilder.WriteByte(hex[c&0x0F])
+		}
 	}
 
-	authorizationString := strings.TrimSuffix(authorizationBuilder.String(), ",")
+	return encodedBuilder.String()
+}
 
-	return authorizationString
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' ||
+		'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
 }
 
 func getSortedKeys(m interface{}) []string {
@@ -260,13 +615,3 @@ func generateRandomBytes(n int) ([]byte, error) {
 
 	return buf, nil
 }
-
-func contains(slice []string, el string) bool {
-	for _, v := range slice {
-		if v == el {
-			return true
-		}
-	}
-
-	return false
-}
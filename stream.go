@@ -0,0 +1,141 @@
+package signer
+
+import (
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+// ErrUnseekableBody is returned by AuthorizationHeaderReader for a body that
+// needs a body hash but can't be rewound, unless spooling is enabled with WithSpoolDir.
+var ErrUnseekableBody = errors.New("signer: body reader is not an io.Seeker and spooling is not enabled")
+
+// WithSpoolDir lets AuthorizationHeaderReader copy bodies that can't be rewound
+// to a temporary file in dir, or in os.TempDir if dir is empty. The file holds
+// the body unencrypted until the returned reader is closed.
+func WithSpoolDir(dir string) Option {
+	return func(s *Signer) {
+		s.spool = true
+		s.spoolDir = dir
+	}
+}
+
+// AuthorizationHeaderReader creates the Authorization header for a body read from
+// r, hashing it while it is read instead of holding it in memory. The returned
+// reader replays the body for sending: an io.Seeker is rewound to where it was.
+// Any other reader fails with ErrUnseekableBody, unless WithSpoolDir is set; it
+// is then written to a temporary file on disk that is removed on Close.
+//
+// The returned reader doesn't tell its length, so http.NewRequest sends it with
+// chunked encoding unless the caller sets the ContentLength of the request.
+func (s *Signer) AuthorizationHeaderReader(uri, method string, r io.Reader) (string, io.ReadCloser, error) {
+	include, err := s.includeBodyHash(method, "")
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !include {
+		authorizationHeader, err := s.authorizationHeader(uri, method, "", "", nil)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		return authorizationHeader, readCloser(r), nil
+	}
+
+	h := s.bodyHashAlgorithm.New()
+
+	body, err := s.hashBody(h, r)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	authorizationHeader, err := s.authorizationHeader(uri, method, "", base64.StdEncoding.EncodeToString(h.Sum(nil)), nil)
+
+	if err != nil {
+		body.Close()
+		return "", nil, err
+	}
+
+	return authorizationHeader, body, nil
+}
+
+// hashBody writes the whole body to h and returns a reader replaying it.
+func (s *Signer) hashBody(h hash.Hash, r io.Reader) (io.ReadCloser, error) {
+	if r == nil {
+		return http.NoBody, nil
+	}
+
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.Copy(h, seeker); err != nil {
+			return nil, err
+		}
+
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		return readCloser(seeker), nil
+	}
+
+	if !s.spool {
+		return nil, ErrUnseekableBody
+	}
+
+	f, err := ioutil.TempFile(s.spoolDir, "oauth1-body-")
+
+	if err != nil {
+		return nil, err
+	}
+
+	spool := &spoolFile{f}
+
+	if _, err := io.Copy(io.MultiWriter(h, f), r); err != nil {
+		spool.Close()
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		spool.Close()
+		return nil, err
+	}
+
+	return spool, nil
+}
+
+// readCloser returns the reader as io.ReadCloser without taking ownership of it.
+func readCloser(r io.Reader) io.ReadCloser {
+	if r == nil {
+		return http.NoBody
+	}
+
+	return ioutil.NopCloser(r)
+}
+
+// spoolFile is a temporary file that is removed when closed.
+type spoolFile struct {
+	*os.File
+}
+
+func (f *spoolFile) Close() error {
+	err := f.File.Close()
+
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+
+	return err
+}
@@ -0,0 +1,159 @@
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// onlyReader hides any other interface of the wrapped reader, like io.Seeker.
+type onlyReader struct {
+	io.Reader
+}
+
+func TestSignerAuthorizationHeaderReader(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "oauth1-spool-test-")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s := NewSigner(consumerKey, privateKey)
+	spooling := NewSigner(consumerKey, privateKey, WithSpoolDir(dir))
+	large := strings.Repeat("0123456789abcdef", 1<<18)
+
+	seekedReader := strings.NewReader("skipped{}")
+	seekedReader.Seek(int64(len("skipped")), io.SeekStart)
+
+	testCases := []struct {
+		name     string
+		signer   *Signer
+		body     io.Reader
+		want     string
+		wantHash string
+		wantErr  error
+	}{
+		{
+			name:     "Nil body",
+			body:     nil,
+			want:     "",
+			wantHash: `oauth_body_hash="47DEQpj8HBSa%2B%2FTImW%2B5JCeuQeRkm5NMpJWZG3hSuFU%3D"`,
+		},
+		{
+			name:     "Seeker",
+			body:     strings.NewReader("{}"),
+			want:     "{}",
+			wantHash: `oauth_body_hash="RBNvo1WzZ4oRRq0W9%2BhknpT7T8If536DEMBg9hyq%2F4o%3D"`,
+		},
+		{
+			name:     "Seeker not at start",
+			body:     seekedReader,
+			want:     "{}",
+			wantHash: `oauth_body_hash="RBNvo1WzZ4oRRq0W9%2BhknpT7T8If536DEMBg9hyq%2F4o%3D"`,
+		},
+		{
+			name:    "Plain reader without spooling",
+			body:    onlyReader{strings.NewReader("{}")},
+			wantErr: ErrUnseekableBody,
+		},
+		{
+			name:     "Plain reader",
+			signer:   spooling,
+			body:     onlyReader{strings.NewReader("{}")},
+			want:     "{}",
+			wantHash: `oauth_body_hash="RBNvo1WzZ4oRRq0W9%2BhknpT7T8If536DEMBg9hyq%2F4o%3D"`,
+		},
+		{
+			name:     "Large plain reader",
+			signer:   spooling,
+			body:     onlyReader{strings.NewReader(large)},
+			want:     large,
+			wantHash: `oauth_body_hash="` + percentEncode(getBodyHash([]byte(large), crypto.SHA256)) + `"`,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			signer := tC.signer
+
+			if signer == nil {
+				signer = s
+			}
+
+			got, body, err := signer.AuthorizationHeaderReader("https://example.com/upload", http.MethodPut, tC.body)
+
+			assertResponseEquality(t, err, tC.wantErr)
+
+			if err != nil {
+				return
+			}
+
+			defer body.Close()
+
+			if !strings.Contains(got, tC.wantHash) {
+				t.Errorf("\ngot '%v'\nshould contain '%v'", got, tC.wantHash)
+			}
+
+			replayed, err := ioutil.ReadAll(body)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(replayed, []byte(tC.want)) {
+				t.Errorf("replayed body of length %v, want length %v", len(replayed), len(tC.want))
+			}
+		})
+	}
+}
+
+func TestSpoolFileRemovedOnClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauth1-spool-test-")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s := NewSigner(consumerKey, nil, WithSpoolDir(dir))
+
+	body, err := s.hashBody(sha256.New(), onlyReader{strings.NewReader("{}")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spool, ok := body.(*spoolFile)
+
+	if !ok {
+		t.Fatalf("got %T, want *spoolFile", body)
+	}
+
+	assertResponseEquality(t, filepath.Dir(spool.Name()), dir)
+
+	if err := spool.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := os.Stat(spool.Name()); !os.IsNotExist(err) {
+		t.Errorf("got '%v', want temporary file to be removed", err)
+	}
+}
@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrFormTransmission is returned when FormTransmission is used for a request
+// whose body isn't form-urlencoded.
+var ErrFormTransmission = errors.New("signer: form transmission requires an application/x-www-form-urlencoded body")
+
+// Transmission is the way SignRequest sends the protocol parameters, as
+// described in RFC 5849 section 3.5.
+type Transmission int
+
+const (
+	// HeaderTransmission sends the parameters in the Authorization header. It is the default.
+	HeaderTransmission Transmission = iota
+
+	// QueryTransmission appends the parameters to the query of the request URL.
+	QueryTransmission
+
+	// FormTransmission appends the parameters to a form-urlencoded request body.
+	FormTransmission
+)
+
+// WithTransmission sets how SignRequest and Transport send the protocol
+// parameters. The realm is only sent with HeaderTransmission.
+func WithTransmission(transmission Transmission) Option {
+	return func(s *Signer) {
+		s.transmission = transmission
+	}
+}
+
+// transmit adds the signed protocol parameters to the request.
+func (s *Signer) transmit(req *http.Request, result *SigningResult, payload []byte) error {
+	switch s.transmission {
+	case QueryTransmission:
+		u := *req.URL
+		u.RawQuery = appendParams(u.RawQuery, result.params)
+		req.URL = &u
+	case FormTransmission:
+		if !isFormContentType(req.Header.Get("Content-Type")) {
+			return ErrFormTransmission
+		}
+
+		setBody(req, []byte(appendParams(string(payload), result.params)))
+	default:
+		req.Header.Set("Authorization", result.Header)
+	}
+
+	return nil
+}
+
+// appendParams appends the percent-encoded parameters, sorted by name, to a
+// query or form-urlencoded body.
+func appendParams(encoded string, params map[string]string) string {
+	var b strings.Builder
+
+	b.WriteString(encoded)
+
+	for _, k := range getSortedKeys(params) {
+		if b.Len() > 0 {
+			b.WriteString("&")
+		}
+
+		b.WriteString(percentEncode(k) + "=" + percentEncode(params[k]))
+	}
+
+	return b.String()
+}
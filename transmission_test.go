@@ -0,0 +1,215 @@
+package signer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// moveParamsToHeader rebuilds the request a server would see with
+// HeaderTransmission, so that Verify can check it.
+func moveParamsToHeader(t *testing.T, req *http.Request, encoded string) *http.Request {
+	t.Helper()
+
+	values, err := url.ParseQuery(encoded)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := AuthorizationHeader{Params: map[string]string{}}
+
+	var rest []string
+
+	for _, param := range strings.Split(encoded, "&") {
+		if !strings.HasPrefix(param, "oauth_") {
+			rest = append(rest, param)
+		}
+	}
+
+	for k, v := range values {
+		if strings.HasPrefix(k, "oauth_") {
+			header.Params[k] = v[0]
+		}
+	}
+
+	if req.Method == http.MethodGet {
+		u := *req.URL
+		u.RawQuery = strings.Join(rest, "&")
+		req.URL = &u
+	} else {
+		req.Body = ioutil.NopCloser(strings.NewReader(strings.Join(rest, "&")))
+	}
+
+	req.Header.Set("Authorization", header.String())
+
+	return req
+}
+
+func TestSignRequestTransmission(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		transmission Transmission
+		method       string
+		uri          string
+		contentType  string
+		body         string
+		wantErr      error
+	}{
+		{
+			name:         "Header",
+			transmission: HeaderTransmission,
+			method:       http.MethodPost,
+			uri:          "https://example.com/request?a=1",
+			contentType:  "application/json",
+			body:         "{}",
+		},
+		{
+			name:         "Query",
+			transmission: QueryTransmission,
+			method:       http.MethodGet,
+			uri:          "https://example.com/request?b=%20&a=1",
+		},
+		{
+			name:         "Query without query",
+			transmission: QueryTransmission,
+			method:       http.MethodGet,
+			uri:          "https://example.com/request",
+		},
+		{
+			name:         "Form",
+			transmission: FormTransmission,
+			method:       http.MethodPost,
+			uri:          "https://example.com/request?a=1",
+			contentType:  "application/x-www-form-urlencoded",
+			body:         "c=2&d=%21",
+		},
+		{
+			name:         "Form without body",
+			transmission: FormTransmission,
+			method:       http.MethodPost,
+			uri:          "https://example.com/request",
+			contentType:  "application/x-www-form-urlencoded",
+		},
+		{
+			name:         "Form with JSON body",
+			transmission: FormTransmission,
+			method:       http.MethodPost,
+			uri:          "https://example.com/request",
+			contentType:  "application/json",
+			body:         "{}",
+			wantErr:      ErrFormTransmission,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(tC.method, tC.uri, strings.NewReader(tC.body))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tC.contentType != "" {
+				req.Header.Set("Content-Type", tC.contentType)
+			}
+
+			s := NewSigner(consumerKey, privateKey, WithTransmission(tC.transmission), WithRealm("Example"))
+
+			err = s.SignRequest(req)
+
+			assertResponseEquality(t, err, tC.wantErr)
+
+			if err != nil {
+				return
+			}
+
+			switch tC.transmission {
+			case HeaderTransmission:
+				if !strings.HasPrefix(req.Header.Get("Authorization"), `OAuth realm="Example",`) {
+					t.Errorf("got Authorization '%v'", req.Header.Get("Authorization"))
+				}
+			case QueryTransmission:
+				assertResponseEquality(t, req.Header.Get("Authorization"), "")
+
+				if !strings.Contains(req.URL.RawQuery, "oauth_signature=") {
+					t.Errorf("\ngot query '%v'\nshould contain 'oauth_signature='", req.URL.RawQuery)
+				}
+
+				req = moveParamsToHeader(t, req, req.URL.RawQuery)
+			case FormTransmission:
+				assertResponseEquality(t, req.Header.Get("Authorization"), "")
+
+				body, err := ioutil.ReadAll(req.Body)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				assertResponseEquality(t, req.ContentLength, int64(len(body)))
+
+				if !strings.HasPrefix(string(body), tC.body) || !strings.Contains(string(body), "oauth_signature=") {
+					t.Errorf("\ngot body '%s'", body)
+				}
+
+				req = moveParamsToHeader(t, req, string(body))
+			}
+
+			assertResponseEquality(t, Verify(req, &privateKey.PublicKey, nil), nil)
+		})
+	}
+}
+
+func TestTransportQueryTransmissionKeepsOriginalURL(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotQuery string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &Transport{
+			Signer: NewSigner(consumerKey, privateKey, WithTransmission(QueryTransmission)),
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/request?a=1", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	assertResponseEquality(t, req.URL.RawQuery, "a=1")
+
+	if !strings.HasPrefix(gotQuery, "a=1&oauth_body_hash=") {
+		t.Errorf("\ngot query '%v'", gotQuery)
+	}
+}
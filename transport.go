@@ -0,0 +1,224 @@
+package signer
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Transport is an http.RoundTripper that adds a Mastercard API compliant OAuth
+// Authorization header, or the parameters of another transmission, to every
+// outgoing request.
+type Transport struct {
+	// Base is the underlying RoundTripper. If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Signer creates the Authorization header.
+	Signer *Signer
+
+	// Skew, if set, learns the clock offset from the Date header of every
+	// response. It should be the clock the Signer was created with.
+	Skew *SkewedClock
+
+	// Encryption, if set, encrypts JSON request bodies before they are signed, so that
+	// oauth_body_hash covers the ciphertext, and decrypts JSON response bodies.
+	Encryption PayloadEncryptor
+}
+
+// PayloadEncryptor encrypts request payloads and decrypts response payloads.
+// Implementations must be safe for concurrent use.
+type PayloadEncryptor interface {
+	EncryptPayload(payload []byte) ([]byte, error)
+	DecryptPayload(payload []byte) ([]byte, error)
+}
+
+// RoundTrip signs a copy of the request and sends it with the Base RoundTripper.
+// The original request is not modified.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signedReq := cloneRequest(req)
+
+	if t.Encryption != nil {
+		if err := encryptRequest(signedReq, t.Encryption); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := t.Signer.SignRequest(signedReq); err != nil {
+		return nil, err
+	}
+
+	res, err := t.base().RoundTrip(signedReq)
+
+	if err == nil && t.Skew != nil {
+		t.Skew.UpdateFromResponse(res)
+	}
+
+	if err == nil && t.Encryption != nil {
+		if err := decryptResponse(res, t.Encryption); err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+	}
+
+	return res, err
+}
+
+// encryptRequest replaces a non-empty JSON request body with its encrypted form.
+// Bodies of other content types are sent as they are.
+func encryptRequest(req *http.Request, e PayloadEncryptor) error {
+	if !isJSONContentType(req.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	payload, err := readBody(req)
+
+	if err != nil || len(payload) == 0 {
+		setBody(req, payload)
+		return err
+	}
+
+	encrypted, err := e.EncryptPayload(payload)
+
+	if err != nil {
+		return err
+	}
+
+	setBody(req, encrypted)
+
+	return nil
+}
+
+// decryptResponse replaces a non-empty JSON response body with its decrypted form.
+func decryptResponse(res *http.Response, e PayloadEncryptor) error {
+	if !isJSONContentType(res.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	payload, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > 0 {
+		if payload, err = e.DecryptPayload(payload); err != nil {
+			return err
+		}
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(payload))
+	res.ContentLength = int64(len(payload))
+	res.Header.Del("Content-Length")
+
+	return nil
+}
+
+// isJSONContentType tells whether the content type is application/json or a +json type.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// SignRequest sets the Authorization header of an outgoing request, or adds the
+// protocol parameters to its query or body as set by WithTransmission. The body is
+// read and replaced with a buffered copy, which GetBody also returns. Parameters
+// of a form-urlencoded body are signed as described in RFC 5849 section 3.4.1.3.1,
+// the body of any other content type is covered by oauth_body_hash.
+func (s *Signer) SignRequest(req *http.Request) error {
+	payload, err := readBody(req)
+
+	if err != nil {
+		return err
+	}
+
+	setBody(req, payload)
+
+	var formBody string
+
+	contentType := req.Header.Get("Content-Type")
+
+	if isFormContentType(contentType) {
+		formBody = string(payload)
+	}
+
+	bodyHash, err := s.getBodyHash(req.Method, contentType, payload)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := s.sign(req.URL.String(), req.Method, formBody, bodyHash, nil)
+
+	if err != nil {
+		return err
+	}
+
+	return s.transmit(req, result, payload)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+
+	return http.DefaultTransport
+}
+
+// readBody reads and closes the request body. A fresh copy from GetBody is
+// preferred, so the original body is left untouched whenever possible.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	var body io.ReadCloser = req.Body
+
+	if req.GetBody != nil {
+		var err error
+
+		body, err = req.GetBody()
+
+		if err != nil {
+			req.Body.Close()
+			return nil, err
+		}
+
+		defer req.Body.Close()
+	}
+
+	defer body.Close()
+
+	return ioutil.ReadAll(body)
+}
+
+// setBody replaces the request body with the buffered payload.
+func setBody(req *http.Request, payload []byte) {
+	if payload == nil {
+		return
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(payload))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(payload)), nil
+	}
+	req.ContentLength = int64(len(payload))
+}
+
+// cloneRequest returns a shallow copy of the request with a deep copy of its headers.
+func cloneRequest(req *http.Request) *http.Request {
+	r := new(http.Request)
+	*r = *req
+
+	r.Header = make(http.Header, len(req.Header))
+
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+
+	return r
+}
@@ -0,0 +1,113 @@
+package signer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransportRoundTrip(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		method   string
+		body     string
+		contains []string
+	}{
+		{
+			name:   "Without body",
+			method: http.MethodGet,
+			body:   "",
+			contains: []string{
+				"OAuth ",
+				`oauth_body_hash="47DEQpj8HBSa%2B%2FTImW%2B5JCeuQeRkm5NMpJWZG3hSuFU%3D"`,
+				`oauth_consumer_key="aaa%21aaa"`,
+				`oauth_signature="`,
+			},
+		},
+		{
+			name:   "With body",
+			method: http.MethodPost,
+			body:   "{}",
+			contains: []string{
+				"OAuth ",
+				`oauth_body_hash="RBNvo1WzZ4oRRq0W9%2BhknpT7T8If536DEMBg9hyq%2F4o%3D"`,
+				`oauth_consumer_key="aaa%21aaa"`,
+				`oauth_signature="`,
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotHeader, gotBody string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader = r.Header.Get("Authorization")
+				body, _ := ioutil.ReadAll(r.Body)
+				gotBody = string(body)
+			}))
+			defer server.Close()
+
+			client := &http.Client{
+				Transport: &Transport{Signer: NewSigner(consumerKey, privateKey)},
+			}
+
+			var req *http.Request
+
+			if tC.body == "" {
+				req, err = http.NewRequest(tC.method, server.URL+"/service?Format=JSON", nil)
+			} else {
+				req, err = http.NewRequest(tC.method, server.URL+"/service?Format=JSON", strings.NewReader(tC.body))
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := client.Do(req)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res.Body.Close()
+
+			for _, v := range tC.contains {
+				if !strings.Contains(gotHeader, v) {
+					t.Errorf("\ngot '%v'\nshould contain '%v'", gotHeader, v)
+				}
+			}
+
+			assertResponseEquality(t, gotBody, tC.body)
+			assertResponseEquality(t, req.Header.Get("Authorization"), "")
+		})
+	}
+}
+
+func TestCloneRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	got := cloneRequest(req)
+	got.Header.Set("Accept", "text/plain")
+
+	assertResponseEquality(t, req.Header.Get("Accept"), "application/json")
+	assertResponseEquality(t, got.Header.Get("Accept"), "text/plain")
+}
@@ -0,0 +1,261 @@
+package signer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+)
+
+// VerifyError is returned by Verify when a request fails OAuth verification.
+type VerifyError string
+
+func (e VerifyError) Error() string {
+	return string(e)
+}
+
+// Reasons for a failed verification.
+const (
+	ErrMissingAuthorization       = VerifyError("signer: missing OAuth Authorization header")
+	ErrMalformedAuthorization     = VerifyError("signer: malformed OAuth Authorization header")
+	ErrMissingParameter           = VerifyError("signer: missing OAuth protocol parameter")
+	ErrUnsupportedVersion         = VerifyError("signer: unsupported OAuth version")
+	ErrUnsupportedSignatureMethod = VerifyError("signer: unsupported OAuth signature method")
+	ErrBodyHashMismatch           = VerifyError("signer: oauth_body_hash does not match request body")
+	ErrInvalidSignature           = VerifyError("signer: invalid OAuth signature")
+	ErrBodyTooLarge               = VerifyError("signer: request body is too large")
+	ErrMalformedRequest           = VerifyError("signer: malformed request query or form body")
+)
+
+// VerifyOptions configures Verify.
+type VerifyOptions struct {
+	// Scheme of the URI the client signed. If empty, it is taken from the request
+	// URL, or is "https" for TLS requests and "http" otherwise.
+	Scheme string
+
+	// Host of the URI the client signed. If empty, it is taken from the request
+	// URL or the Host header.
+	Host string
+
+	// BodyHashAlgorithm is the hash of oauth_body_hash. If zero, crypto.SHA256 is used.
+	BodyHashAlgorithm crypto.Hash
+
+	// BodyHashPolicy decides which requests must carry oauth_body_hash, and
+	// should match the policy of the clients. If nil, AlwaysBodyHash is used.
+	// A body hash sent with any other request is still checked.
+	BodyHashPolicy BodyHashPolicy
+
+	// MaxBodyBytes limits the size of the request body, which is read into memory
+	// before the signature is checked. Larger bodies fail with ErrBodyTooLarge.
+	// If zero or negative, Verify doesn't limit the size. An Authenticator limits
+	// it to 1 MiB if zero.
+	MaxBodyBytes int64
+}
+
+// Verify checks the OAuth Authorization header of a received request signed with
+// RSA-SHA256 or RSA-SHA1. The body is read to check oauth_body_hash, or to sign
+// its parameters if it is form-urlencoded, and is restored for the following handlers.
+func Verify(req *http.Request, publicKey *rsa.PublicKey, opts *VerifyOptions) error {
+	s, err := parseSignedRequest(req, opts)
+
+	if err != nil {
+		return err
+	}
+
+	if !s.verify(publicKey) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// signedRequest is a received request whose parameters and body passed all
+// checks, ready to be verified against public keys.
+type signedRequest struct {
+	hash      crypto.Hash
+	digest    []byte
+	signature []byte
+}
+
+// parseSignedRequest checks the protocol parameters and the body hash of a
+// received request, and computes the digest of its signature base string.
+func parseSignedRequest(req *http.Request, opts *VerifyOptions) (*signedRequest, error) {
+	header, err := ParseAuthorizationHeader(req.Header.Get("Authorization"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	oauthParams := header.Params
+
+	for _, k := range []string{
+		"oauth_consumer_key",
+		"oauth_nonce",
+		"oauth_signature",
+		"oauth_signature_method",
+		"oauth_timestamp",
+	} {
+		if _, ok := oauthParams[k]; !ok {
+			return nil, ErrMissingParameter
+		}
+	}
+
+	if v, ok := oauthParams["oauth_version"]; ok && v != "1.0" {
+		return nil, ErrUnsupportedVersion
+	}
+
+	var hash crypto.Hash
+
+	switch oauthParams["oauth_signature_method"] {
+	case RSASHA256{}.Name():
+		hash = crypto.SHA256
+	case RSASHA1{}.Name():
+		hash = crypto.SHA1
+	default:
+		return nil, ErrUnsupportedSignatureMethod
+	}
+
+	var maxBodyBytes int64
+
+	if opts != nil {
+		maxBodyBytes = opts.MaxBodyBytes
+	}
+
+	payload, err := readAndRestoreBody(req, maxBodyBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var formBody string
+
+	contentType := req.Header.Get("Content-Type")
+
+	if isFormContentType(contentType) {
+		formBody = string(payload)
+	} else if err := checkBodyHash(oauthParams, req.Method, contentType, payload, opts); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(oauthParams["oauth_signature"])
+
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	delete(oauthParams, "oauth_signature")
+
+	sbs, err := buildSignatureBaseString(requestURI(req, opts), req.Method, formBody, oauthParams)
+
+	if err != nil {
+		return nil, ErrMalformedRequest
+	}
+
+	h := hash.New()
+	h.Write([]byte(sbs))
+
+	return &signedRequest{hash: hash, digest: h.Sum(nil), signature: signature}, nil
+}
+
+// verify tells whether the request was signed with the private key of publicKey.
+func (s *signedRequest) verify(publicKey *rsa.PublicKey) bool {
+	return rsa.VerifyPKCS1v15(publicKey, s.hash, s.digest, s.signature) == nil
+}
+
+// checkBodyHash compares oauth_body_hash with the hash of the payload. A missing
+// body hash is accepted if the body hash policy doesn't require one.
+func checkBodyHash(oauthParams map[string]string, method, contentType string, payload []byte, opts *VerifyOptions) error {
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+
+	bodyHash, ok := oauthParams["oauth_body_hash"]
+
+	if !ok {
+		policy := opts.BodyHashPolicy
+
+		if policy == nil {
+			policy = AlwaysBodyHash
+		}
+
+		if policy(method, contentType) {
+			return ErrMissingParameter
+		}
+
+		return nil
+	}
+
+	bodyHashAlgorithm := crypto.SHA256
+
+	if opts.BodyHashAlgorithm != 0 {
+		bodyHashAlgorithm = opts.BodyHashAlgorithm
+	}
+
+	if !bodyHashAlgorithm.Available() {
+		return ErrBodyHashUnavailable
+	}
+
+	if getBodyHash(payload, bodyHashAlgorithm) != bodyHash {
+		return ErrBodyHashMismatch
+	}
+
+	return nil
+}
+
+// readAndRestoreBody reads the request body and replaces it with an unread copy.
+// Bodies larger than maxBytes fail with ErrBodyTooLarge, unless maxBytes is zero.
+func readAndRestoreBody(req *http.Request, maxBytes int64) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body := req.Body
+
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(nil, body, maxBytes)
+	}
+
+	payload, err := ioutil.ReadAll(body)
+	body.Close()
+
+	if err != nil && maxBytes > 0 && int64(len(payload)) >= maxBytes {
+		return nil, ErrBodyTooLarge
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(payload))
+
+	return payload, nil
+}
+
+// requestURI returns the absolute URI of a received request.
+func requestURI(req *http.Request, opts *VerifyOptions) string {
+	u := *req.URL
+
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+
+	switch {
+	case opts.Scheme != "":
+		u.Scheme = opts.Scheme
+	case u.Scheme == "" && req.TLS != nil:
+		u.Scheme = "https"
+	case u.Scheme == "":
+		u.Scheme = "http"
+	}
+
+	switch {
+	case opts.Host != "":
+		u.Host = opts.Host
+	case u.Host == "":
+		u.Host = req.Host
+	}
+
+	return u.String()
+}
@@ -0,0 +1,474 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 1024)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSigner(consumerKey, privateKey)
+	uri := "https://sandbox.api.mastercard.com/service?Format=JSON&Type=ExactMatch"
+
+	signedHeader, err := s.AuthorizationHeader(uri, http.MethodPost, "{}")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sha1Header, err := NewSigner(consumerKey, nil, WithSignatureMethod(RSASHA1{Key: privateKey})).AuthorizationHeader(uri, http.MethodPost, "{}")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		target    string
+		body      string
+		header    string
+		publicKey *rsa.PublicKey
+		want      error
+	}{
+		{
+			name:      "Valid",
+			target:    uri,
+			body:      "{}",
+			header:    signedHeader,
+			publicKey: &privateKey.PublicKey,
+			want:      nil,
+		},
+		{
+			name:      "Valid RSA-SHA1",
+			target:    uri,
+			body:      "{}",
+			header:    sha1Header,
+			publicKey: &privateKey.PublicKey,
+			want:      nil,
+		},
+		{
+			name:      "Missing header",
+			target:    uri,
+			body:      "{}",
+			header:    "",
+			publicKey: &privateKey.PublicKey,
+			want:      ErrMissingAuthorization,
+		},
+		{
+			name:      "Other scheme",
+			target:    uri,
+			body:      "{}",
+			header:    "Basic ZGV2OnNlY3JldA==",
+			publicKey: &privateKey.PublicKey,
+			want:      ErrMalformedAuthorization,
+		},
+		{
+			name:      "Unquoted value",
+			target:    uri,
+			body:      "{}",
+			header:    `OAuth oauth_consumer_key=aaa`,
+			publicKey: &privateKey.PublicKey,
+			want:      ErrMalformedAuthorization,
+		},
+		{
+			name:      "Missing parameter",
+			target:    uri,
+			body:      "{}",
+			header:    `OAuth oauth_consumer_key="aaa"`,
+			publicKey: &privateKey.PublicKey,
+			want:      ErrMissingParameter,
+		},
+		{
+			name:      "Unsupported version",
+			target:    uri,
+			body:      "{}",
+			header:    strings.Replace(signedHeader, `oauth_version="1.0"`, `oauth_version="2.0"`, 1),
+			publicKey: &privateKey.PublicKey,
+			want:      ErrUnsupportedVersion,
+		},
+		{
+			name:      "Unsupported signature method",
+			target:    uri,
+			body:      "{}",
+			header:    strings.Replace(signedHeader, `"RSA-SHA256"`, `"HMAC-SHA1"`, 1),
+			publicKey: &privateKey.PublicKey,
+			want:      ErrUnsupportedSignatureMethod,
+		},
+		{
+			name:      "Tampered body",
+			target:    uri,
+			body:      `{"amount":100}`,
+			header:    signedHeader,
+			publicKey: &privateKey.PublicKey,
+			want:      ErrBodyHashMismatch,
+		},
+		{
+			name:      "Tampered query",
+			target:    "https://sandbox.api.mastercard.com/service?Format=XML&Type=ExactMatch",
+			body:      "{}",
+			header:    signedHeader,
+			publicKey: &privateKey.PublicKey,
+			want:      ErrInvalidSignature,
+		},
+		{
+			name:      "Malformed query",
+			target:    "https://sandbox.api.mastercard.com/service?Format=%zz&Type=ExactMatch",
+			body:      "{}",
+			header:    signedHeader,
+			publicKey: &privateKey.PublicKey,
+			want:      ErrMalformedRequest,
+		},
+		{
+			name:      "Other key",
+			target:    uri,
+			body:      "{}",
+			header:    signedHeader,
+			publicKey: &otherKey.PublicKey,
+			want:      ErrInvalidSignature,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, tC.target, strings.NewReader(tC.body))
+
+			if tC.header != "" {
+				req.Header.Set("Authorization", tC.header)
+			}
+
+			got := Verify(req, tC.publicKey, nil)
+
+			assertResponseEquality(t, got, tC.want)
+		})
+	}
+}
+
+func TestVerifyRestoresBody(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := NewSigner(consumerKey, privateKey).AuthorizationHeader("https://example.com/service", http.MethodPost, "{}")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/service", strings.NewReader("{}"))
+	req.Header.Set("Authorization", header)
+
+	err = Verify(req, &privateKey.PublicKey, &VerifyOptions{Scheme: "https"})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	assertResponseEquality(t, string(body), "{}")
+}
+
+func TestRequestURI(t *testing.T) {
+	testCases := []struct {
+		name   string
+		target string
+		opts   *VerifyOptions
+		want   string
+	}{
+		{
+			name:   "Absolute target",
+			target: "https://example.com/service?a=1",
+			opts:   nil,
+			want:   "https://example.com/service?a=1",
+		},
+		{
+			name:   "Origin form target",
+			target: "/service?a=1",
+			opts:   nil,
+			want:   "http://example.com/service?a=1",
+		},
+		{
+			name:   "Overridden scheme and host",
+			target: "/service?a=1",
+			opts:   &VerifyOptions{Scheme: "https", Host: "api.example.com"},
+			want:   "https://api.example.com/service?a=1",
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tC.target, nil)
+
+			got := requestURI(req, tC.opts)
+
+			assertResponseEquality(t, got, tC.want)
+		})
+	}
+}
+
+func TestVerifyBodyHashAlgorithm(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSigner(consumerKey, privateKey, WithBodyHashAlgorithm(crypto.SHA1))
+
+	testCases := []struct {
+		name string
+		opts *VerifyOptions
+		want error
+	}{
+		{
+			name: "Matching algorithm",
+			opts: &VerifyOptions{BodyHashAlgorithm: crypto.SHA1},
+			want: nil,
+		},
+		{
+			name: "Default algorithm",
+			opts: nil,
+			want: ErrBodyHashMismatch,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "https://example.com/service", strings.NewReader("{}"))
+
+			if err := s.SignRequest(req); err != nil {
+				t.Fatal(err)
+			}
+
+			got := Verify(req, &privateKey.PublicKey, tC.opts)
+
+			assertResponseEquality(t, got, tC.want)
+		})
+	}
+}
+
+func TestVerifyBodyHashPolicy(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skipGet := func(method, contentType string) bool {
+		return method != http.MethodGet
+	}
+
+	testCases := []struct {
+		name   string
+		signer *Signer
+		method string
+		opts   *VerifyOptions
+		want   error
+	}{
+		{
+			name:   "Matching policy",
+			signer: NewSigner(consumerKey, privateKey, WithBodyHashPolicy(skipGet)),
+			method: http.MethodGet,
+			opts:   &VerifyOptions{BodyHashPolicy: skipGet},
+			want:   nil,
+		},
+		{
+			name:   "Matching policy with body hash",
+			signer: NewSigner(consumerKey, privateKey, WithBodyHashPolicy(skipGet)),
+			method: http.MethodPost,
+			opts:   &VerifyOptions{BodyHashPolicy: skipGet},
+			want:   nil,
+		},
+		{
+			name:   "Never",
+			signer: NewSigner(consumerKey, privateKey, WithBodyHashPolicy(NeverBodyHash)),
+			method: http.MethodPost,
+			opts:   &VerifyOptions{BodyHashPolicy: NeverBodyHash},
+			want:   nil,
+		},
+		{
+			name:   "Default policy",
+			signer: NewSigner(consumerKey, privateKey, WithBodyHashPolicy(skipGet)),
+			method: http.MethodGet,
+			opts:   nil,
+			want:   ErrMissingParameter,
+		},
+		{
+			name:   "Required by policy",
+			signer: NewSigner(consumerKey, privateKey, WithBodyHashPolicy(NeverBodyHash)),
+			method: http.MethodPost,
+			opts:   &VerifyOptions{BodyHashPolicy: skipGet},
+			want:   ErrMissingParameter,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tC.method, "https://example.com/service", strings.NewReader("{}"))
+
+			if err := tC.signer.SignRequest(req); err != nil {
+				t.Fatal(err)
+			}
+
+			got := Verify(req, &privateKey.PublicKey, tC.opts)
+
+			assertResponseEquality(t, got, tC.want)
+		})
+	}
+}
+
+func TestVerifyMaxBodyBytes(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSigner(consumerKey, privateKey)
+
+	testCases := []struct {
+		name string
+		body string
+		opts *VerifyOptions
+		want error
+	}{
+		{
+			name: "No limit",
+			body: strings.Repeat("a", 1024),
+			opts: nil,
+			want: nil,
+		},
+		{
+			name: "At limit",
+			body: strings.Repeat("a", 16),
+			opts: &VerifyOptions{MaxBodyBytes: 16},
+			want: nil,
+		},
+		{
+			name: "Over limit",
+			body: strings.Repeat("a", 17),
+			opts: &VerifyOptions{MaxBodyBytes: 16},
+			want: ErrBodyTooLarge,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "https://example.com/service", strings.NewReader(tC.body))
+
+			if err := s.SignRequest(req); err != nil {
+				t.Fatal(err)
+			}
+
+			got := Verify(req, &privateKey.PublicKey, tC.opts)
+
+			assertResponseEquality(t, got, tC.want)
+		})
+	}
+}
+
+func TestVerifyFormBody(t *testing.T) {
+	privateKey, err := ParsePrivateKey([]byte(signingKey))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSigner(consumerKey, privateKey)
+
+	testCases := []struct {
+		name string
+		body string
+		want error
+	}{
+		{
+			name: "Same body",
+			body: "c2&a3=2+q",
+			want: nil,
+		},
+		{
+			name: "Reordered body",
+			body: "a3=2%20q&c2=",
+			want: nil,
+		},
+		{
+			name: "Tampered body",
+			body: "c2&a3=3+q",
+			want: ErrInvalidSignature,
+		},
+		{
+			name: "Malformed body",
+			body: "c2&a3=%zz",
+			want: ErrMalformedRequest,
+		},
+	}
+
+	for _, tC := range testCases {
+		tC := tC
+
+		t.Run(tC.name, func(t *testing.T) {
+			t.Parallel()
+
+			signed := httptest.NewRequest(http.MethodPost, "http://example.com/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b", strings.NewReader("c2&a3=2+q"))
+			signed.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			if err := s.SignRequest(signed); err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(signed.Header.Get("Authorization"), "oauth_body_hash") {
+				t.Errorf("got '%v', want no oauth_body_hash for a form body", signed.Header.Get("Authorization"))
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b", strings.NewReader(tC.body))
+			req.Header = signed.Header
+
+			got := Verify(req, &privateKey.PublicKey, nil)
+
+			assertResponseEquality(t, got, tC.want)
+		})
+	}
+}